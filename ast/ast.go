@@ -3,12 +3,15 @@ package ast
 import (
 	"bytes"
 	"interpreter/token"
+	"strconv"
 	"strings"
 )
 
 type Node interface {
 	TokenLiteral() string
 	String() string
+	// Pos - позиція токена, з якого створено вузол
+	Pos() token.Position
 }
 
 type Expression interface {
@@ -34,6 +37,13 @@ func (p *Program) String() string {
 	return out.String()
 }
 
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+
 func (p *Program) TokenLiteral() string {
 	if len(p.Statements) > 0 {
 		return p.Statements[0].TokenLiteral()
@@ -46,6 +56,8 @@ type LetStatement struct {
 	Token token.Token
 	Name  *Identifier
 	Value Expression
+	// Doc - /// коментар перед let або const
+	Doc string
 }
 
 func (l *LetStatement) String() string {
@@ -63,10 +75,19 @@ func (l *LetStatement) String() string {
 	return out.String()
 }
 
+// IsConst - чи оголошено ім'я через const
+func (l *LetStatement) IsConst() bool {
+	return l.Token.Type == token.CONST
+}
+
 func (l *LetStatement) TokenLiteral() string {
 	return l.Token.Literal
 }
 
+func (l *LetStatement) Pos() token.Position {
+	return l.Token.Pos
+}
+
 func (l *LetStatement) statementNode() {
 
 }
@@ -94,6 +115,10 @@ func (rs *ReturnStatement) TokenLiteral() string {
 	return rs.Token.Literal
 }
 
+func (rs *ReturnStatement) Pos() token.Position {
+	return rs.Token.Pos
+}
+
 type Identifier struct {
 	Token token.Token
 	Value string
@@ -109,6 +134,10 @@ func (ident *Identifier) TokenLiteral() string {
 	return ident.Token.Literal
 }
 
+func (ident *Identifier) Pos() token.Position {
+	return ident.Token.Pos
+}
+
 type ExpressionStatement struct {
 	Token      token.Token
 	Expression Expression
@@ -127,6 +156,10 @@ func (es *ExpressionStatement) TokenLiteral() string {
 	return es.Token.Literal
 }
 
+func (es *ExpressionStatement) Pos() token.Position {
+	return es.Token.Pos
+}
+
 type IntegerLiteral struct {
 	Token token.Token
 	Value int64
@@ -138,10 +171,52 @@ func (il *IntegerLiteral) TokenLiteral() string {
 	return il.Token.Literal
 }
 
+func (il *IntegerLiteral) Pos() token.Position {
+	return il.Token.Pos
+}
+
 func (il *IntegerLiteral) String() string {
 	return il.Token.Literal
 }
 
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode() {}
+
+func (fl *FloatLiteral) TokenLiteral() string {
+	return fl.Token.Literal
+}
+
+func (fl *FloatLiteral) Pos() token.Position {
+	return fl.Token.Pos
+}
+
+func (fl *FloatLiteral) String() string {
+	return fl.Token.Literal
+}
+
+type StringLiteral struct {
+	Token token.Token
+	Value string
+}
+
+func (sl *StringLiteral) expressionNode() {}
+
+func (sl *StringLiteral) TokenLiteral() string {
+	return sl.Token.Literal
+}
+
+func (sl *StringLiteral) Pos() token.Position {
+	return sl.Token.Pos
+}
+
+func (sl *StringLiteral) String() string {
+	return strconv.Quote(sl.Value)
+}
+
 type PrefixExpression struct {
 	Token    token.Token
 	Operator string
@@ -156,6 +231,10 @@ func (p *PrefixExpression) TokenLiteral() string {
 	return p.Token.Literal
 }
 
+func (p *PrefixExpression) Pos() token.Position {
+	return p.Token.Pos
+}
+
 func (p *PrefixExpression) String() string {
 	var out bytes.Buffer
 
@@ -180,6 +259,10 @@ func (i *InfixExpression) TokenLiteral() string {
 	return i.Token.Literal
 }
 
+func (i *InfixExpression) Pos() token.Position {
+	return i.Token.Pos
+}
+
 func (i *InfixExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
@@ -191,6 +274,29 @@ func (i *InfixExpression) String() string {
 	return out.String()
 }
 
+// AssignExpression - Target = Value або складене присвоєння, наприклад Target += Value.
+// Target - *Identifier або *IndexExpression
+type AssignExpression struct {
+	Token    token.Token // токен оператора
+	Target   Expression
+	Operator string
+	Value    Expression
+}
+
+func (a *AssignExpression) expressionNode() {}
+
+func (a *AssignExpression) TokenLiteral() string {
+	return a.Token.Literal
+}
+
+func (a *AssignExpression) Pos() token.Position {
+	return a.Token.Pos
+}
+
+func (a *AssignExpression) String() string {
+	return "(" + a.Target.String() + " " + a.Operator + " " + a.Value.String() + ")"
+}
+
 type Boolean struct {
 	Token token.Token
 	Value bool
@@ -208,6 +314,10 @@ func (b *Boolean) TokenLiteral() string {
 	return b.Token.Literal
 }
 
+func (b *Boolean) Pos() token.Position {
+	return b.Token.Pos
+}
+
 type IfExpression struct {
 	Token       token.Token
 	Condition   Expression
@@ -220,6 +330,10 @@ func (i *IfExpression) TokenLiteral() string {
 	return i.Token.Literal
 }
 
+func (i *IfExpression) Pos() token.Position {
+	return i.Token.Pos
+}
+
 func (i *IfExpression) String() string {
 	var out bytes.Buffer
 
@@ -234,6 +348,107 @@ func (i *IfExpression) String() string {
 	return out.String()
 }
 
+// ConditionalExpression - тернарний оператор cond ? a : b. на відміну від if,
+// гілки - вирази, а не блоки
+type ConditionalExpression struct {
+	Token       token.Token // '?'
+	Condition   Expression
+	Consequence Expression
+	Alternative Expression
+}
+
+func (ce *ConditionalExpression) expressionNode() {}
+
+func (ce *ConditionalExpression) TokenLiteral() string {
+	return ce.Token.Literal
+}
+
+func (ce *ConditionalExpression) Pos() token.Position {
+	return ce.Token.Pos
+}
+
+func (ce *ConditionalExpression) String() string {
+	return "(" + ce.Condition.String() + " ? " + ce.Consequence.String() + " : " + ce.Alternative.String() + ")"
+}
+
+type WhileStatement struct {
+	Token     token.Token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (ws *WhileStatement) statementNode() {}
+
+func (ws *WhileStatement) TokenLiteral() string {
+	return ws.Token.Literal
+}
+
+func (ws *WhileStatement) Pos() token.Position {
+	return ws.Token.Pos
+}
+
+func (ws *WhileStatement) String() string {
+	return "while " + ws.Condition.String() + " {" + ws.Body.String() + "}"
+}
+
+// ForStatement - for (Variable in Iterable) { Body }
+type ForStatement struct {
+	Token    token.Token
+	Variable *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (fs *ForStatement) statementNode() {}
+
+func (fs *ForStatement) TokenLiteral() string {
+	return fs.Token.Literal
+}
+
+func (fs *ForStatement) Pos() token.Position {
+	return fs.Token.Pos
+}
+
+func (fs *ForStatement) String() string {
+	return "for (" + fs.Variable.String() + " in " + fs.Iterable.String() + ") {" + fs.Body.String() + "}"
+}
+
+type BreakStatement struct {
+	Token token.Token
+}
+
+func (bs *BreakStatement) statementNode() {}
+
+func (bs *BreakStatement) TokenLiteral() string {
+	return bs.Token.Literal
+}
+
+func (bs *BreakStatement) Pos() token.Position {
+	return bs.Token.Pos
+}
+
+func (bs *BreakStatement) String() string {
+	return bs.Token.Literal + ";"
+}
+
+type ContinueStatement struct {
+	Token token.Token
+}
+
+func (cs *ContinueStatement) statementNode() {}
+
+func (cs *ContinueStatement) TokenLiteral() string {
+	return cs.Token.Literal
+}
+
+func (cs *ContinueStatement) Pos() token.Position {
+	return cs.Token.Pos
+}
+
+func (cs *ContinueStatement) String() string {
+	return cs.Token.Literal + ";"
+}
+
 type BlockStatement struct {
 	Token      token.Token
 	Statements []Statement
@@ -251,12 +466,18 @@ func (bs *BlockStatement) TokenLiteral() string {
 	return bs.Token.Literal
 }
 
+func (bs *BlockStatement) Pos() token.Position {
+	return bs.Token.Pos
+}
+
 func (bs *BlockStatement) statementNode() {}
 
 type FunctionLiteral struct {
 	Token      token.Token
 	Parameters []*Identifier
 	Body       BlockStatement
+	// Doc - /// коментар перед fn або перед let, значенням якого є функція
+	Doc string
 }
 
 func (fl *FunctionLiteral) expressionNode() {
@@ -285,10 +506,16 @@ func (fl *FunctionLiteral) TokenLiteral() string {
 	return fl.Token.Literal
 }
 
+func (fl *FunctionLiteral) Pos() token.Position {
+	return fl.Token.Pos
+}
+
 type CallExpression struct {
 	Token     token.Token
 	Function  Expression //це буде або функція або ідентіфаєр
 	Arguments []Expression
+	// Optional - виклик через f?.(x): якщо f - NULL, результат NULL, аргументи не обчислюються
+	Optional bool
 }
 
 func (ce *CallExpression) expressionNode() {}
@@ -304,6 +531,9 @@ func (ce *CallExpression) String() string {
 
 	out.WriteString(ce.Function.String())
 
+	if ce.Optional {
+		out.WriteString("?.")
+	}
 	out.WriteString("(")
 	out.WriteString(strings.Join(args, ", "))
 	out.WriteString(")")
@@ -315,3 +545,147 @@ func (ce *CallExpression) String() string {
 func (ce *CallExpression) TokenLiteral() string {
 	return ce.Token.Literal
 }
+
+func (ce *CallExpression) Pos() token.Position {
+	return ce.Token.Pos
+}
+
+type ArrayLiteral struct {
+	Token    token.Token
+	Elements []Expression
+}
+
+func (al *ArrayLiteral) expressionNode() {}
+
+func (al *ArrayLiteral) TokenLiteral() string {
+	return al.Token.Literal
+}
+
+func (al *ArrayLiteral) Pos() token.Position {
+	return al.Token.Pos
+}
+
+func (al *ArrayLiteral) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, el := range al.Elements {
+		elements = append(elements, el.String())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+type IndexExpression struct {
+	Token token.Token
+	Left  Expression
+	Index Expression
+	// Optional - індекс через a?.[i]: якщо a - NULL, результат NULL, індекс не обчислюється
+	Optional bool
+}
+
+func (ie *IndexExpression) expressionNode() {}
+
+func (ie *IndexExpression) TokenLiteral() string {
+	return ie.Token.Literal
+}
+
+func (ie *IndexExpression) Pos() token.Position {
+	return ie.Token.Pos
+}
+
+func (ie *IndexExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ie.Left.String())
+	if ie.Optional {
+		out.WriteString("?.")
+	}
+	out.WriteString("[")
+	out.WriteString(ie.Index.String())
+	out.WriteString("])")
+
+	return out.String()
+}
+
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+// HashLiteral зберігає пари у порядку появи в коді,
+// щоб обчислення і String() були детермінованими
+type HashLiteral struct {
+	Token token.Token
+	Pairs []HashPair
+}
+
+func (hl *HashLiteral) expressionNode() {}
+
+func (hl *HashLiteral) TokenLiteral() string {
+	return hl.Token.Literal
+}
+
+func (hl *HashLiteral) Pos() token.Position {
+	return hl.Token.Pos
+}
+
+func (hl *HashLiteral) String() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range hl.Pairs {
+		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// BadStatement займає місце інструкції, яку не вдалось розпарсити,
+// щоб решта програми лишалась доступною для інструментів
+type BadStatement struct {
+	Token token.Token // перший токен зламаної інструкції
+	End   token.Position
+}
+
+func (bs *BadStatement) statementNode() {}
+
+func (bs *BadStatement) TokenLiteral() string {
+	return bs.Token.Literal
+}
+
+func (bs *BadStatement) Pos() token.Position {
+	return bs.Token.Pos
+}
+
+func (bs *BadStatement) String() string {
+	return "<bad statement>"
+}
+
+// BadExpression - вираз, що починається з токена, який не може почати вираз
+type BadExpression struct {
+	Token token.Token
+}
+
+func (be *BadExpression) expressionNode() {}
+
+func (be *BadExpression) TokenLiteral() string {
+	return be.Token.Literal
+}
+
+func (be *BadExpression) Pos() token.Position {
+	return be.Token.Pos
+}
+
+func (be *BadExpression) String() string {
+	return "<bad expression>"
+}
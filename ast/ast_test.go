@@ -34,3 +34,46 @@ func TestAstString(t *testing.T) {
 		t.Errorf("program.String() wrong. got=%q", program.String())
 	}
 }
+
+func TestDump(t *testing.T) {
+	ident := func(name string, col int) *Identifier {
+		return &Identifier{
+			Token: token.Token{Type: token.IDENT, Literal: name, Pos: token.Position{Line: 1, Column: col}},
+			Value: name,
+		}
+	}
+	program := &Program{
+		Statements: []Statement{
+			&LetStatement{
+				Token: token.Token{Type: token.LET, Literal: "let", Pos: token.Position{Line: 1, Column: 1}},
+				Name:  ident("f", 5),
+				Value: &FunctionLiteral{
+					Token:      token.Token{Type: token.FUNCTION, Literal: "fn", Pos: token.Position{Line: 1, Column: 9}},
+					Parameters: []*Identifier{ident("x", 12)},
+					Body: BlockStatement{
+						Token: token.Token{Type: token.LBRACE, Literal: "{", Pos: token.Position{Line: 1, Column: 15}},
+						Statements: []Statement{
+							&ExpressionStatement{
+								Token:      token.Token{Type: token.IDENT, Literal: "x", Pos: token.Position{Line: 1, Column: 17}},
+								Expression: ident("x", 17),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	expected := `Program "let" 1:1
+  Statements[0]: LetStatement "let" 1:1
+    Name: Identifier "f" 1:5
+    Value: FunctionLiteral "fn" 1:9
+      Parameters[0]: Identifier "x" 1:12
+      Body: BlockStatement "{" 1:15
+        Statements[0]: ExpressionStatement "x" 1:17
+          Expression: Identifier "x" 1:17
+`
+	if got := Dump(program); got != expected {
+		t.Errorf("Dump wrong.\nexpected:\n%s\ngot:\n%s", expected, got)
+	}
+}
@@ -0,0 +1,60 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var nodeType = reflect.TypeOf((*Node)(nil)).Elem()
+
+// Dump повертає дерево вузла з відступами: тип, літерал токена і позиція
+// для кожного вузла, дочірні вузли - з назвою поля. Використовується в REPL (:ast)
+func Dump(node Node) string {
+	var out bytes.Buffer
+	dumpValue(&out, "", reflect.ValueOf(node), 0)
+	return out.String()
+}
+
+func dumpValue(out *bytes.Buffer, label string, v reflect.Value, depth int) {
+	if (v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer) && v.IsNil() {
+		return
+	}
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	// BlockStatement в FunctionLiteral зберігається за значенням
+	if v.Kind() == reflect.Struct && v.CanAddr() && v.Addr().Type().Implements(nodeType) {
+		v = v.Addr()
+	}
+
+	indent := strings.Repeat("  ", depth)
+	switch {
+	case v.Type().Implements(nodeType):
+		node := v.Interface().(Node)
+		fmt.Fprintf(out, "%s%s%s %q %s\n", indent, label, v.Type().Elem().Name(), node.TokenLiteral(), node.Pos())
+		dumpFields(out, v.Elem(), depth+1)
+	case v.Kind() == reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			dumpValue(out, fmt.Sprintf("%s[%d]", strings.TrimSuffix(label, ": "), i)+": ", v.Index(i), depth)
+		}
+	case v.Kind() == reflect.Struct:
+		fmt.Fprintf(out, "%s%s%s\n", indent, label, v.Type().Name())
+		dumpFields(out, v, depth+1)
+	case label == "Doc: " && v.String() != "":
+		fmt.Fprintf(out, "%s%s%q\n", indent, label, v.String())
+	case label == "Optional: " && v.Bool():
+		fmt.Fprintf(out, "%s%strue\n", indent, label)
+	}
+}
+
+func dumpFields(out *bytes.Buffer, v reflect.Value, depth int) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Name == "Token" || !field.IsExported() {
+			continue
+		}
+		dumpValue(out, field.Name+": ", v.Field(i), depth)
+	}
+}
@@ -0,0 +1,771 @@
+package compiler
+
+import (
+	"fmt"
+	"interpreter/ast"
+	"interpreter/code"
+	"interpreter/object"
+	"interpreter/token"
+	"strings"
+)
+
+// Compiler перетворює ast.Program на байткод для vm.
+// семантика та сама, що в evaluator.Eval: ті ж результати і ті ж тексти помилок
+type Compiler struct {
+	constants []object.Object
+
+	symbolTable *SymbolTable
+
+	scopes     []CompilationScope
+	scopeIndex int
+
+	// position - місце в коді вузла, що зараз компілюється. його отримують емітовані інструкції
+	position token.Position
+
+	// operandErr - перший операнд, що не влазить у свою ширину. emit не повертає помилок,
+	// тому Compile повертає її після вузла
+	operandErr error
+}
+
+type EmittedInstruction struct {
+	Opcode   code.Opcode
+	Position int
+}
+
+// CompilationScope - інструкції однієї функції, що зараз компілюється
+type CompilationScope struct {
+	instructions        code.Instructions
+	lastInstruction     EmittedInstruction
+	previousInstruction EmittedInstruction
+
+	// positions - позиції інструкцій за їхнім зсувом, див. object.CompiledFunction.Positions
+	positions map[int]token.Position
+
+	// loops - цикли, всередині яких зараз компілюється код, найближчий останній
+	loops []*loopContext
+}
+
+// loopContext - куди стрибають continue і break одного циклу
+type loopContext struct {
+	continuePos int
+	// breakJumps - позиції OpJump для break, виправляються в кінці циклу
+	breakJumps []int
+}
+
+// Bytecode - результат компіляції
+type Bytecode struct {
+	Instructions code.Instructions
+	Constants    []object.Object
+	// Globals - індекси глобальних змінних за іменем, для OpGetName
+	Globals map[string]int
+	// ConstGlobals - глобальні константи, яким OpSetName не може присвоїти
+	ConstGlobals map[string]bool
+	// Positions - позиції інструкцій програми, див. object.CompiledFunction.Positions
+	Positions map[int]token.Position
+}
+
+func New() *Compiler {
+	return &Compiler{
+		constants:   []object.Object{},
+		symbolTable: NewSymbolTable(),
+		scopes:      []CompilationScope{{instructions: code.Instructions{}, positions: map[int]token.Position{}}},
+	}
+}
+
+// NewWithState продовжує компіляцію з таблицею символів і константами попереднього запуску (REPL)
+func NewWithState(s *SymbolTable, constants []object.Object) *Compiler {
+	compiler := New()
+	compiler.symbolTable = s
+	compiler.constants = constants
+	return compiler
+}
+
+func (c *Compiler) Compile(node ast.Node) error {
+	if err := c.compileNode(node); err != nil {
+		return err
+	}
+	return c.operandErr
+}
+
+func (c *Compiler) compileNode(node ast.Node) error {
+	// помилка виконання інструкції вказує на найглибший вузол, що її емітував, як і в evaluator
+	if node != nil {
+		outer := c.position
+		c.position = node.Pos()
+		defer func() { c.position = outer }()
+	}
+
+	switch node := node.(type) {
+	case *ast.Program:
+		for _, s := range node.Statements {
+			if err := c.Compile(s); err != nil {
+				return err
+			}
+		}
+		// як і evaluator, програма, що закінчується не виразом, дає null
+		if len(node.Statements) == 0 || !isExpressionStatement(node.Statements[len(node.Statements)-1]) {
+			c.emit(code.OpNull)
+			c.emit(code.OpPop)
+		}
+
+	case *ast.ExpressionStatement:
+		if err := c.Compile(node.Expression); err != nil {
+			return err
+		}
+		c.emit(code.OpPop)
+
+	case *ast.BlockStatement:
+		for _, s := range node.Statements {
+			if err := c.Compile(s); err != nil {
+				return err
+			}
+		}
+
+	case *ast.LetStatement:
+		// функція може викликати сама себе: ім'я оголошується до компіляції тіла
+		// і захоплюється, як будь-яка інша змінна
+		fn, isFunction := node.Value.(*ast.FunctionLiteral)
+		define := c.symbolTable.Define
+		if node.IsConst() {
+			define = c.symbolTable.DefineConst
+		}
+		var symbol Symbol
+		if isFunction {
+			symbol = define(node.Name.Value)
+			if err := c.compileFunction(fn); err != nil {
+				return err
+			}
+		} else {
+			if err := c.Compile(node.Value); err != nil {
+				return err
+			}
+			symbol = define(node.Name.Value)
+		}
+
+		c.storeSymbol(symbol)
+
+	case *ast.WhileStatement:
+		if err := c.compileWhileStatement(node); err != nil {
+			return err
+		}
+
+	case *ast.ForStatement:
+		if err := c.compileForStatement(node); err != nil {
+			return err
+		}
+
+	case *ast.BreakStatement:
+		loop := c.currentLoop()
+		if loop == nil {
+			return fmt.Errorf("break outside of a loop")
+		}
+		c.emit(code.OpUnwindLoop)
+		loop.breakJumps = append(loop.breakJumps, c.emit(code.OpJump, 9999))
+
+	case *ast.ContinueStatement:
+		loop := c.currentLoop()
+		if loop == nil {
+			return fmt.Errorf("continue outside of a loop")
+		}
+		c.emit(code.OpUnwindLoop)
+		c.emit(code.OpJump, loop.continuePos)
+
+	case *ast.ReturnStatement:
+		if err := c.Compile(node.ReturnValue); err != nil {
+			return err
+		}
+		c.emit(code.OpReturnValue)
+
+	case *ast.Identifier:
+		symbol, ok := c.symbolTable.Resolve(node.Value)
+		if !ok {
+			// ім'я може бути оголошене глобально пізніше і затінити вбудовану функцію -
+			// перевіряється під час виконання, як і в evaluator
+			c.emit(code.OpGetName, c.addConstant(&object.String{Value: node.Value}))
+			return nil
+		}
+		c.loadSymbol(symbol)
+
+	case *ast.IntegerLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.Integer{Value: node.Value}))
+
+	case *ast.FloatLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.Float{Value: node.Value}))
+
+	case *ast.StringLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.String{Value: node.Value}))
+
+	case *ast.Boolean:
+		if node.Value {
+			c.emit(code.OpTrue)
+		} else {
+			c.emit(code.OpFalse)
+		}
+
+	case *ast.PrefixExpression:
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+
+		switch node.Operator {
+		case "!":
+			c.emit(code.OpBang)
+		case "-":
+			c.emit(code.OpMinus)
+		case "~":
+			c.emit(code.OpBitNot)
+		default:
+			return fmt.Errorf("unknown operator %s", node.Operator)
+		}
+
+	case *ast.InfixExpression:
+		if node.Operator == "&&" || node.Operator == "||" {
+			return c.compileLogicalExpression(node)
+		}
+		if node.Operator == "??" {
+			return c.compileCoalesceExpression(node)
+		}
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+
+		op, ok := infixOpcodes[node.Operator]
+		if !ok {
+			return fmt.Errorf("unknown operator %s", node.Operator)
+		}
+		c.emit(op)
+
+	case *ast.AssignExpression:
+		if err := c.compileAssignExpression(node); err != nil {
+			return err
+		}
+
+	case *ast.IfExpression:
+		if err := c.compileIfExpression(node); err != nil {
+			return err
+		}
+
+	case *ast.ConditionalExpression:
+		if err := c.compileConditionalExpression(node); err != nil {
+			return err
+		}
+
+	case *ast.FunctionLiteral:
+		if err := c.compileFunction(node); err != nil {
+			return err
+		}
+
+	case *ast.CallExpression:
+		if err := c.Compile(node.Function); err != nil {
+			return err
+		}
+		jumpNullPos := c.emitOptionalJump(node.Optional)
+		for _, a := range node.Arguments {
+			if err := c.Compile(a); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpCall, len(node.Arguments))
+		c.patchOptionalJump(jumpNullPos)
+
+	case *ast.ArrayLiteral:
+		for _, el := range node.Elements {
+			if err := c.Compile(el); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpArray, len(node.Elements))
+
+	case *ast.HashLiteral:
+		for _, pair := range node.Pairs {
+			if err := c.Compile(pair.Key); err != nil {
+				return err
+			}
+			if err := c.Compile(pair.Value); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpHash, len(node.Pairs)*2)
+
+	case *ast.IndexExpression:
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+		jumpNullPos := c.emitOptionalJump(node.Optional)
+		if err := c.Compile(node.Index); err != nil {
+			return err
+		}
+		c.emit(code.OpIndex)
+		c.patchOptionalJump(jumpNullPos)
+
+	default:
+		return fmt.Errorf("compiler: unsupported node %T", node)
+	}
+
+	return nil
+}
+
+var infixOpcodes = map[string]code.Opcode{
+	"+":  code.OpAdd,
+	"-":  code.OpSub,
+	"*":  code.OpMul,
+	"/":  code.OpDiv,
+	"%":  code.OpMod,
+	"&":  code.OpBitAnd,
+	"|":  code.OpBitOr,
+	"^":  code.OpBitXor,
+	"<<": code.OpShiftLeft,
+	">>": code.OpShiftRight,
+	">":  code.OpGreaterThan,
+	"<":  code.OpLessThan,
+	">=": code.OpGreaterThanOrEqual,
+	"<=": code.OpLessThanOrEqual,
+	"==": code.OpEqual,
+	"!=": code.OpNotEqual,
+}
+
+// compileLogicalExpression перестрибує правий операнд, якщо результат відомий з лівого.
+// правий операнд перетворюється на BOOLEAN через !!, як в evaluator
+func (c *Compiler) compileLogicalExpression(node *ast.InfixExpression) error {
+	if err := c.Compile(node.Left); err != nil {
+		return err
+	}
+	jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+	if node.Operator == "&&" {
+		if err := c.compileTruthiness(node.Right); err != nil {
+			return err
+		}
+		jumpPos := c.emit(code.OpJump, 9999)
+		// хибний лівий операнд
+		c.changeOperand(jumpNotTruthyPos, c.emit(code.OpFalse))
+		c.changeOperand(jumpPos, len(c.currentInstructions()))
+		return nil
+	}
+
+	// правдивий лівий операнд
+	c.emit(code.OpTrue)
+	jumpPos := c.emit(code.OpJump, 9999)
+	c.changeOperand(jumpNotTruthyPos, len(c.currentInstructions()))
+	if err := c.compileTruthiness(node.Right); err != nil {
+		return err
+	}
+	c.changeOperand(jumpPos, len(c.currentInstructions()))
+	return nil
+}
+
+// compileCoalesceExpression: якщо лівий операнд не NULL, він і є результатом,
+// інакше він знімається зі стеку і обчислюється правий
+func (c *Compiler) compileCoalesceExpression(node *ast.InfixExpression) error {
+	if err := c.Compile(node.Left); err != nil {
+		return err
+	}
+	jumpNullPos := c.emit(code.OpJumpNull, 9999)
+	jumpPos := c.emit(code.OpJump, 9999)
+
+	c.changeOperand(jumpNullPos, c.emit(code.OpPop))
+	if err := c.Compile(node.Right); err != nil {
+		return err
+	}
+	c.changeOperand(jumpPos, len(c.currentInstructions()))
+	return nil
+}
+
+func (c *Compiler) compileConditionalExpression(node *ast.ConditionalExpression) error {
+	if err := c.Compile(node.Condition); err != nil {
+		return err
+	}
+	jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+	if err := c.Compile(node.Consequence); err != nil {
+		return err
+	}
+	jumpPos := c.emit(code.OpJump, 9999)
+
+	c.changeOperand(jumpNotTruthyPos, len(c.currentInstructions()))
+	if err := c.Compile(node.Alternative); err != nil {
+		return err
+	}
+	c.changeOperand(jumpPos, len(c.currentInstructions()))
+	return nil
+}
+
+// emitOptionalJump для a?.[i] і f?.(x) перестрибує решту виразу, якщо a чи f - NULL:
+// тоді NULL лишається на стеку як результат. повертає -1, якщо операція не optional
+func (c *Compiler) emitOptionalJump(optional bool) int {
+	if !optional {
+		return -1
+	}
+	return c.emit(code.OpJumpNull, 9999)
+}
+
+func (c *Compiler) patchOptionalJump(pos int) {
+	if pos >= 0 {
+		c.changeOperand(pos, len(c.currentInstructions()))
+	}
+}
+
+// compileAssignExpression лишає присвоєне значення на стеку: присвоєння - це вираз
+func (c *Compiler) compileAssignExpression(node *ast.AssignExpression) error {
+	var operator code.Opcode
+	if node.Operator != "=" {
+		op, ok := infixOpcodes[strings.TrimSuffix(node.Operator, "=")]
+		if !ok {
+			return fmt.Errorf("unknown operator %s", node.Operator)
+		}
+		operator = op
+	}
+
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		symbol, ok := c.symbolTable.Resolve(target.Value)
+		if ok && symbol.Constant {
+			return fmt.Errorf("cannot assign to constant: %s", target.Value)
+		}
+
+		if operator != 0 {
+			if err := c.Compile(target); err != nil {
+				return err
+			}
+		}
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+		if operator != 0 {
+			c.emit(operator)
+		}
+
+		if ok {
+			c.storeSymbol(symbol)
+			c.loadSymbol(symbol)
+			return nil
+		}
+		// як і OpGetName: глобальна змінна може бути оголошена пізніше
+		name := c.addConstant(&object.String{Value: target.Value})
+		c.emit(code.OpSetName, name)
+		c.emit(code.OpGetName, name)
+
+	case *ast.IndexExpression:
+		if err := c.Compile(target.Left); err != nil {
+			return err
+		}
+		if err := c.Compile(target.Index); err != nil {
+			return err
+		}
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+		c.emit(code.OpSetIndex, int(operator))
+
+	default:
+		return fmt.Errorf("invalid assignment target: %s", node.Target.String())
+	}
+	return nil
+}
+
+func (c *Compiler) compileTruthiness(node ast.Expression) error {
+	if err := c.Compile(node); err != nil {
+		return err
+	}
+	c.emit(code.OpBang)
+	c.emit(code.OpBang)
+	return nil
+}
+
+func (c *Compiler) compileIfExpression(node *ast.IfExpression) error {
+	if err := c.Compile(node.Condition); err != nil {
+		return err
+	}
+
+	// операнд виправляється, коли стане відома адреса
+	jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+	if err := c.compileBlockValue(node.Consequence); err != nil {
+		return err
+	}
+
+	jumpPos := c.emit(code.OpJump, 9999)
+	c.changeOperand(jumpNotTruthyPos, len(c.currentInstructions()))
+
+	if node.Alternative == nil {
+		c.emit(code.OpNull)
+	} else if err := c.compileBlockValue(node.Alternative); err != nil {
+		return err
+	}
+
+	c.changeOperand(jumpPos, len(c.currentInstructions()))
+	return nil
+}
+
+// compileWhileStatement перевіряє умову перед кожним проходом. цикл нічого не лишає на стеку
+func (c *Compiler) compileWhileStatement(node *ast.WhileStatement) error {
+	c.emit(code.OpEnterLoop)
+	startPos := len(c.currentInstructions())
+	if err := c.Compile(node.Condition); err != nil {
+		return err
+	}
+	jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+	return c.compileLoopBody(node.Body, startPos, jumpNotTruthyPos)
+}
+
+// compileForStatement тримає ітератор у прихованій змінній, а не на стеку,
+// тому break і return з тіла не мусять його знімати
+func (c *Compiler) compileForStatement(node *ast.ForStatement) error {
+	if err := c.Compile(node.Iterable); err != nil {
+		return err
+	}
+	c.emit(code.OpIter)
+
+	// '#' не може бути в імені зі скрипту. вкладені цикли мають різні імена
+	iterator := c.symbolTable.Define(fmt.Sprintf("for#%d", len(c.scopes[c.scopeIndex].loops)))
+	c.storeSymbol(iterator)
+
+	c.emit(code.OpEnterLoop)
+	nextPos := len(c.currentInstructions())
+	c.loadSymbol(iterator)
+	iterNextPos := c.emit(code.OpIterNext, 9999)
+	c.storeSymbol(c.symbolTable.Define(node.Variable.Value))
+
+	return c.compileLoopBody(node.Body, nextPos, iterNextPos)
+}
+
+// compileLoopBody компілює тіло зі стрибком назад на continuePos. стрибок виходу exitJump
+// і стрибки break ведуть на OpLeaveLoop, що знімає мітку стеку циклу
+func (c *Compiler) compileLoopBody(body *ast.BlockStatement, continuePos, exitJump int) error {
+	scope := &c.scopes[c.scopeIndex]
+	loop := &loopContext{continuePos: continuePos}
+	scope.loops = append(scope.loops, loop)
+
+	if err := c.Compile(body); err != nil {
+		return err
+	}
+	c.emit(code.OpJump, continuePos)
+
+	// scope перечитується: вкладені функції могли змінити c.scopes
+	scope = &c.scopes[c.scopeIndex]
+	scope.loops = scope.loops[:len(scope.loops)-1]
+	c.changeOperand(exitJump, len(c.currentInstructions()))
+	for _, pos := range loop.breakJumps {
+		c.changeOperand(pos, len(c.currentInstructions()))
+	}
+	c.emit(code.OpLeaveLoop)
+	return nil
+}
+
+func (c *Compiler) currentLoop() *loopContext {
+	loops := c.scopes[c.scopeIndex].loops
+	if len(loops) == 0 {
+		return nil
+	}
+	return loops[len(loops)-1]
+}
+
+// compileBlockValue компілює блок так, щоб його значення лишилось на стеку.
+// блок, що закінчується не виразом, дає null
+func (c *Compiler) compileBlockValue(block *ast.BlockStatement) error {
+	if err := c.Compile(block); err != nil {
+		return err
+	}
+
+	if c.lastInstructionIs(code.OpPop) {
+		c.removeLastPop()
+	} else if !c.lastInstructionIs(code.OpReturnValue) {
+		c.emit(code.OpNull)
+	}
+	return nil
+}
+
+func (c *Compiler) compileFunction(node *ast.FunctionLiteral) error {
+	c.enterScope()
+
+	for _, p := range node.Parameters {
+		c.symbolTable.Define(p.Value)
+	}
+
+	if err := c.Compile(&node.Body); err != nil {
+		return err
+	}
+
+	if c.lastInstructionIs(code.OpPop) {
+		c.replaceLastPopWithReturn()
+	}
+	if !c.lastInstructionIs(code.OpReturnValue) {
+		c.emit(code.OpReturn)
+	}
+
+	freeSymbols := c.symbolTable.FreeSymbols
+	numLocals := c.symbolTable.numDefinitions
+	positions := c.scopes[c.scopeIndex].positions
+	instructions := c.leaveScope()
+
+	for _, s := range freeSymbols {
+		c.captureSymbol(s)
+	}
+
+	compiledFn := &object.CompiledFunction{
+		Instructions:  instructions,
+		NumLocals:     numLocals,
+		NumParameters: len(node.Parameters),
+		Positions:     positions,
+	}
+
+	c.emit(code.OpClosure, c.addConstant(compiledFn), len(freeSymbols))
+	return nil
+}
+
+func (c *Compiler) storeSymbol(s Symbol) {
+	switch s.Scope {
+	case GlobalScope:
+		c.emit(code.OpSetGlobal, s.Index)
+	case LocalScope:
+		c.emit(code.OpSetLocal, s.Index)
+	case FreeScope:
+		c.emit(code.OpSetFree, s.Index)
+	}
+}
+
+// captureSymbol кладе на стек вільну змінну для OpClosure: комірку, а не значення,
+// щоб присвоєння у функції і в замиканні змінювали одну змінну
+func (c *Compiler) captureSymbol(s Symbol) {
+	switch s.Scope {
+	case LocalScope:
+		c.emit(code.OpCaptureLocal, s.Index)
+	case FreeScope:
+		c.emit(code.OpCaptureFree, s.Index)
+	}
+}
+
+func (c *Compiler) loadSymbol(s Symbol) {
+	switch s.Scope {
+	case GlobalScope:
+		c.emit(code.OpGetGlobal, s.Index)
+	case LocalScope:
+		c.emit(code.OpGetLocal, s.Index)
+	case FreeScope:
+		c.emit(code.OpGetFree, s.Index)
+	}
+}
+
+func (c *Compiler) Bytecode() *Bytecode {
+	return &Bytecode{
+		Instructions: c.currentInstructions(),
+		Constants:    c.constants,
+		Globals:      c.symbolTable.globals(),
+		ConstGlobals: c.symbolTable.constGlobals(),
+		Positions:    c.scopes[c.scopeIndex].positions,
+	}
+}
+
+// SymbolTable потрібна, щоб передати стан у наступний компілятор (NewWithState)
+func (c *Compiler) SymbolTable() *SymbolTable {
+	return c.symbolTable
+}
+
+func (c *Compiler) addConstant(obj object.Object) int {
+	c.constants = append(c.constants, obj)
+	return len(c.constants) - 1
+}
+
+func (c *Compiler) emit(op code.Opcode, operands ...int) int {
+	c.checkOperands(op, operands)
+	ins := code.Make(op, operands...)
+	pos := c.addInstruction(ins)
+	if c.position.IsValid() {
+		c.scopes[c.scopeIndex].positions[pos] = c.position
+	}
+
+	c.setLastInstruction(op, pos)
+
+	return pos
+}
+
+func (c *Compiler) addInstruction(ins []byte) int {
+	posNewInstruction := len(c.currentInstructions())
+	c.scopes[c.scopeIndex].instructions = append(c.currentInstructions(), ins...)
+	return posNewInstruction
+}
+
+func (c *Compiler) setLastInstruction(op code.Opcode, pos int) {
+	previous := c.scopes[c.scopeIndex].lastInstruction
+	last := EmittedInstruction{Opcode: op, Position: pos}
+
+	c.scopes[c.scopeIndex].previousInstruction = previous
+	c.scopes[c.scopeIndex].lastInstruction = last
+}
+
+func (c *Compiler) currentInstructions() code.Instructions {
+	return c.scopes[c.scopeIndex].instructions
+}
+
+func (c *Compiler) lastInstructionIs(op code.Opcode) bool {
+	if len(c.currentInstructions()) == 0 {
+		return false
+	}
+	return c.scopes[c.scopeIndex].lastInstruction.Opcode == op
+}
+
+func (c *Compiler) removeLastPop() {
+	last := c.scopes[c.scopeIndex].lastInstruction
+	previous := c.scopes[c.scopeIndex].previousInstruction
+
+	c.scopes[c.scopeIndex].instructions = c.currentInstructions()[:last.Position]
+	c.scopes[c.scopeIndex].lastInstruction = previous
+}
+
+func (c *Compiler) replaceLastPopWithReturn() {
+	lastPos := c.scopes[c.scopeIndex].lastInstruction.Position
+	c.replaceInstruction(lastPos, code.Make(code.OpReturnValue))
+	c.scopes[c.scopeIndex].lastInstruction.Opcode = code.OpReturnValue
+}
+
+func (c *Compiler) replaceInstruction(pos int, newInstruction []byte) {
+	ins := c.currentInstructions()
+	copy(ins[pos:], newInstruction)
+}
+
+func (c *Compiler) changeOperand(opPos int, operand int) {
+	op := code.Opcode(c.currentInstructions()[opPos])
+	c.checkOperands(op, []int{operand})
+	newInstruction := code.Make(op, operand)
+	c.replaceInstruction(opPos, newInstruction)
+}
+
+// checkOperands запам'ятовує помилку, якщо операнд не влазить у свою ширину:
+// code.Make мовчки обрізав би його, і vm читала б інший слот чи стрибала не туди
+func (c *Compiler) checkOperands(op code.Opcode, operands []int) {
+	def, err := code.Lookup(byte(op))
+	if err != nil || c.operandErr != nil {
+		return
+	}
+	for i, o := range operands {
+		if limit := 1<<(8*def.OperandWidths[i]) - 1; o > limit {
+			c.operandErr = fmt.Errorf("program too large: %s operand %d exceeds %d", def.Name, o, limit)
+			return
+		}
+	}
+}
+
+func (c *Compiler) enterScope() {
+	c.scopes = append(c.scopes, CompilationScope{instructions: code.Instructions{}, positions: map[int]token.Position{}})
+	c.scopeIndex++
+	c.symbolTable = NewEnclosedSymbolTable(c.symbolTable)
+}
+
+func (c *Compiler) leaveScope() code.Instructions {
+	instructions := c.currentInstructions()
+
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.scopeIndex--
+	c.symbolTable = c.symbolTable.Outer
+
+	return instructions
+}
+
+func isExpressionStatement(s ast.Statement) bool {
+	_, ok := s.(*ast.ExpressionStatement)
+	return ok
+}
@@ -0,0 +1,112 @@
+package compiler
+
+type SymbolScope string
+
+const (
+	GlobalScope SymbolScope = "GLOBAL"
+	LocalScope  SymbolScope = "LOCAL"
+	FreeScope   SymbolScope = "FREE"
+)
+
+type Symbol struct {
+	Name  string
+	Scope SymbolScope
+	Index int
+	// Constant - ім'я оголошене через const
+	Constant bool
+}
+
+// SymbolTable відповідає object.Environment: одна таблиця на функцію, Outer - на оточуючу
+type SymbolTable struct {
+	Outer *SymbolTable
+
+	store          map[string]Symbol
+	numDefinitions int
+
+	FreeSymbols []Symbol
+}
+
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{store: make(map[string]Symbol)}
+}
+
+func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+	s := NewSymbolTable()
+	s.Outer = outer
+	return s
+}
+
+// Define оголошує ім'я в цьому скоупі. повторний let того ж імені в тому ж скоупі
+// використовує той самий слот - як environment.Set, що перезаписує значення
+func (s *SymbolTable) Define(name string) Symbol {
+	return s.define(name, false)
+}
+
+// DefineConst оголошує константу. присвоєння їй - помилка компіляції
+func (s *SymbolTable) DefineConst(name string) Symbol {
+	return s.define(name, true)
+}
+
+func (s *SymbolTable) define(name string, constant bool) Symbol {
+	symbol, ok := s.store[name]
+	if !ok || (symbol.Scope != GlobalScope && symbol.Scope != LocalScope) {
+		symbol = Symbol{Name: name, Index: s.numDefinitions}
+		if s.Outer == nil {
+			symbol.Scope = GlobalScope
+		} else {
+			symbol.Scope = LocalScope
+		}
+		s.numDefinitions++
+	}
+
+	symbol.Constant = constant
+	s.store[name] = symbol
+	return symbol
+}
+
+func (s *SymbolTable) defineFree(original Symbol) Symbol {
+	s.FreeSymbols = append(s.FreeSymbols, original)
+
+	symbol := Symbol{Name: original.Name, Index: len(s.FreeSymbols) - 1, Scope: FreeScope, Constant: original.Constant}
+	s.store[original.Name] = symbol
+	return symbol
+}
+
+func (s *SymbolTable) Resolve(name string) (Symbol, bool) {
+	obj, ok := s.store[name]
+	if !ok && s.Outer != nil {
+		obj, ok = s.Outer.Resolve(name)
+		if !ok {
+			return obj, ok
+		}
+
+		if obj.Scope == GlobalScope {
+			return obj, ok
+		}
+
+		return s.defineFree(obj), true
+	}
+	return obj, ok
+}
+
+// constGlobals повертає імена глобальних констант
+func (s *SymbolTable) constGlobals() map[string]bool {
+	names := make(map[string]bool)
+	for name, symbol := range s.store {
+		if symbol.Scope == GlobalScope && symbol.Constant {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// globals повертає індекси всіх глобальних імен
+func (s *SymbolTable) globals() map[string]int {
+	names := make(map[string]int)
+	for name, symbol := range s.store {
+		if symbol.Scope == GlobalScope {
+			names[name] = symbol.Index
+		}
+	}
+	return names
+}
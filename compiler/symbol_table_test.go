@@ -0,0 +1,66 @@
+package compiler
+
+import "testing"
+
+func TestResolveNestedLocal(t *testing.T) {
+	global := NewSymbolTable()
+	global.Define("a")
+
+	firstLocal := NewEnclosedSymbolTable(global)
+	firstLocal.Define("c")
+
+	secondLocal := NewEnclosedSymbolTable(firstLocal)
+	secondLocal.Define("e")
+
+	tests := []struct {
+		table    *SymbolTable
+		expected []Symbol
+	}{
+		{firstLocal, []Symbol{
+			{Name: "a", Scope: GlobalScope, Index: 0},
+			{Name: "c", Scope: LocalScope, Index: 0},
+		}},
+		{secondLocal, []Symbol{
+			{Name: "a", Scope: GlobalScope, Index: 0},
+			{Name: "c", Scope: FreeScope, Index: 0},
+			{Name: "e", Scope: LocalScope, Index: 0},
+		}},
+	}
+
+	for _, tt := range tests {
+		for _, sym := range tt.expected {
+			result, ok := tt.table.Resolve(sym.Name)
+			if !ok {
+				t.Errorf("name %s not resolvable", sym.Name)
+				continue
+			}
+			if result != sym {
+				t.Errorf("expected %s to resolve to %+v, got=%+v", sym.Name, sym, result)
+			}
+		}
+	}
+
+	if len(secondLocal.FreeSymbols) != 1 || secondLocal.FreeSymbols[0].Name != "c" {
+		t.Errorf("wrong free symbols. got=%+v", secondLocal.FreeSymbols)
+	}
+}
+
+func TestRedefineReusesSlot(t *testing.T) {
+	global := NewSymbolTable()
+	first := global.Define("a")
+	global.Define("b")
+	second := global.Define("a")
+
+	if first != second {
+		t.Errorf("redefinition got a new slot. first=%+v, second=%+v", first, second)
+	}
+}
+
+func TestUnresolvable(t *testing.T) {
+	global := NewSymbolTable()
+	local := NewEnclosedSymbolTable(global)
+
+	if _, ok := local.Resolve("x"); ok {
+		t.Errorf("x resolved, but was never defined")
+	}
+}
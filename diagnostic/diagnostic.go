@@ -0,0 +1,171 @@
+package diagnostic
+
+import (
+	"encoding/json"
+	"fmt"
+	"interpreter/object"
+	"interpreter/token"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+	Note
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	case Note:
+		return "note"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// Span - ділянка коду [Start, End). якщо End не задано, підкреслюється один символ
+type Span struct {
+	Start token.Position
+	End   token.Position
+}
+
+// TokenSpan повертає ділянку, яку займає токен
+func TokenSpan(tok token.Token) Span {
+	return Span{Start: tok.Pos, End: tok.End}
+}
+
+type Diagnostic struct {
+	Severity Severity
+	Span     Span
+	Message  string
+	// Hint - необов'язкова підказка як виправити помилку
+	Hint string
+}
+
+// Error форматує діагностику в один рядок: "line:column: message"
+func (d Diagnostic) Error() string {
+	return d.Span.Start.String() + ": " + d.Message
+}
+
+// FromRuntimeError перетворює помилку виконання на діагностику з позицією, де вона виникла
+func FromRuntimeError(err *object.Error) Diagnostic {
+	return Diagnostic{
+		Severity: Error,
+		Span:     Span{Start: err.Pos},
+		Message:  err.Message,
+	}
+}
+
+// Render друкує діагностики в стилі rustc/clang: заголовок, рядок коду з
+// підкресленням ^~~~ та підказку. source - текст, з якого отримано позиції
+func Render(w io.Writer, source string, diags []Diagnostic) {
+	lines := strings.Split(source, "\n")
+	for _, d := range diags {
+		renderOne(w, lines, d)
+	}
+}
+
+func renderOne(w io.Writer, lines []string, d Diagnostic) {
+	fmt.Fprintf(w, "%s: %s\n", d.Severity, d.Message)
+
+	start := d.Span.Start
+	if !start.IsValid() || start.Line > len(lines) {
+		if d.Hint != "" {
+			fmt.Fprintf(w, "  = hint: %s\n", d.Hint)
+		}
+		return
+	}
+
+	line := strings.TrimRight(lines[start.Line-1], "\r")
+	lineNo := fmt.Sprintf("%d", start.Line)
+	gutter := strings.Repeat(" ", len(lineNo))
+
+	fmt.Fprintf(w, "%s--> %s\n", gutter, start)
+	fmt.Fprintf(w, "%s |\n", gutter)
+	fmt.Fprintf(w, "%s | %s\n", lineNo, line)
+	fmt.Fprintf(w, "%s | %s%s\n", gutter, padding(line, start.Column), underline(line, d.Span))
+	if d.Hint != "" {
+		fmt.Fprintf(w, "%s = hint: %s\n", gutter, d.Hint)
+	}
+}
+
+// padding повторює табуляції з рядка коду, щоб ^ стала під потрібним символом.
+// колонки рахуються в символах, а не в байтах
+func padding(line string, column int) string {
+	runes := []rune(line)
+	var out strings.Builder
+	for i := 0; i < column-1; i++ {
+		if i < len(runes) && runes[i] == '\t' {
+			out.WriteByte('\t')
+		} else {
+			out.WriteByte(' ')
+		}
+	}
+	return out.String()
+}
+
+// underline малює ^ під першим символом ділянки і ~ під рештою.
+// багаторядкові ділянки підкреслюються до кінця першого рядка
+func underline(line string, span Span) string {
+	length := 1
+	if span.End.IsValid() {
+		if span.End.Line == span.Start.Line {
+			length = span.End.Column - span.Start.Column
+		} else {
+			length = utf8.RuneCountInString(line) - span.Start.Column + 1
+		}
+	}
+	if length < 1 {
+		length = 1
+	}
+	return "^" + strings.Repeat("~", length-1)
+}
+
+type jsonPosition struct {
+	Filename string `json:"filename,omitempty"`
+	Offset   int    `json:"offset"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+type jsonDiagnostic struct {
+	Severity string       `json:"severity"`
+	Message  string       `json:"message"`
+	Hint     string       `json:"hint,omitempty"`
+	Start    jsonPosition `json:"start"`
+	End      jsonPosition `json:"end"`
+}
+
+func toJSONPosition(p token.Position) jsonPosition {
+	return jsonPosition{Filename: p.Filename, Offset: p.Offset, Line: p.Line, Column: p.Column}
+}
+
+func (d Diagnostic) MarshalJSON() ([]byte, error) {
+	end := d.Span.End
+	if !end.IsValid() {
+		end = d.Span.Start
+	}
+	return json.Marshal(jsonDiagnostic{
+		Severity: d.Severity.String(),
+		Message:  d.Message,
+		Hint:     d.Hint,
+		Start:    toJSONPosition(d.Span.Start),
+		End:      toJSONPosition(end),
+	})
+}
+
+// RenderJSON пише діагностики JSON-масивом для інтеграції з редакторами
+func RenderJSON(w io.Writer, diags []Diagnostic) error {
+	if diags == nil {
+		diags = []Diagnostic{}
+	}
+	return json.NewEncoder(w).Encode(diags)
+}
@@ -0,0 +1,109 @@
+package diagnostic
+
+import (
+	"bytes"
+	"interpreter/object"
+	"interpreter/token"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	source := "let x = 1;\n\tadd(foo, 2;\nціна + foo\n"
+
+	tests := []struct {
+		diag     Diagnostic
+		expected string
+	}{
+		{
+			Diagnostic{
+				Severity: Error,
+				Span: Span{
+					Start: token.Position{Line: 2, Column: 6, Offset: 16},
+					End:   token.Position{Line: 2, Column: 9, Offset: 19},
+				},
+				Message: "identifier not found: foo",
+			},
+			"error: identifier not found: foo\n" +
+				" --> 2:6\n" +
+				"  |\n" +
+				"2 | \tadd(foo, 2;\n" +
+				"  | \t    ^~~\n",
+		},
+		{
+			Diagnostic{
+				Severity: Warning,
+				Span:     Span{Start: token.Position{Filename: "a.monkey", Line: 1, Column: 5}},
+				Message:  "unused",
+				Hint:     "remove it",
+			},
+			"warning: unused\n" +
+				" --> a.monkey:1:5\n" +
+				"  |\n" +
+				"1 | let x = 1;\n" +
+				"  |     ^\n" +
+				"  = hint: remove it\n",
+		},
+		{
+			// колонки рахуються в символах: "ціна" - 4 колонки, 8 байтів
+			Diagnostic{
+				Severity: Error,
+				Span: Span{
+					Start: token.Position{Line: 3, Column: 10, Offset: 33},
+					End:   token.Position{Line: 3, Column: 13, Offset: 36},
+				},
+				Message: "identifier not found: foo",
+			},
+			"error: identifier not found: foo\n" +
+				" --> 3:10\n" +
+				"  |\n" +
+				"3 | ціна + foo\n" +
+				"  |          ^~~\n",
+		},
+		{
+			Diagnostic{Severity: Error, Message: "no position", Hint: "h"},
+			"error: no position\n  = hint: h\n",
+		},
+	}
+
+	for i, tt := range tests {
+		var buf bytes.Buffer
+		Render(&buf, source, []Diagnostic{tt.diag})
+		if buf.String() != tt.expected {
+			t.Errorf("tests[%d] - wrong render.\nexpected:\n%s\ngot:\n%s", i, tt.expected, buf.String())
+		}
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	diags := []Diagnostic{
+		{
+			Severity: Error,
+			Span: Span{
+				Start: token.Position{Line: 1, Column: 1, Offset: 0},
+				End:   token.Position{Line: 1, Column: 4, Offset: 3},
+			},
+			Message: "bad",
+			Hint:    "fix",
+		},
+		FromRuntimeError(&object.Error{Message: "boom", Pos: token.Position{Filename: "f", Line: 2, Column: 3, Offset: 7}}),
+	}
+
+	var buf bytes.Buffer
+	if err := RenderJSON(&buf, diags); err != nil {
+		t.Fatalf("RenderJSON returned error: %s", err)
+	}
+
+	expected := `[{"severity":"error","message":"bad","hint":"fix",` +
+		`"start":{"offset":0,"line":1,"column":1},"end":{"offset":3,"line":1,"column":4}},` +
+		`{"severity":"error","message":"boom",` +
+		`"start":{"filename":"f","offset":7,"line":2,"column":3},"end":{"filename":"f","offset":7,"line":2,"column":3}}]` + "\n"
+	if buf.String() != expected {
+		t.Errorf("wrong json.\nexpected=%s\ngot=%s", expected, buf.String())
+	}
+
+	buf.Reset()
+	RenderJSON(&buf, nil)
+	if buf.String() != "[]\n" {
+		t.Errorf("wrong json for no diagnostics. got=%q", buf.String())
+	}
+}
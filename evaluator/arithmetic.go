@@ -0,0 +1,141 @@
+package evaluator
+
+import (
+	"interpreter/object"
+	"math"
+	"math/big"
+)
+
+// ArithmeticMode визначає, що відбувається з цілим, яке не влазить в int64
+type ArithmeticMode int
+
+const (
+	// ArithmeticChecked - переповнення дає помилку "integer overflow"
+	ArithmeticChecked ArithmeticMode = iota
+	// ArithmeticBig - результат непомітно для скрипта стає цілим довільної точності
+	ArithmeticBig
+)
+
+func evalBigIntegerInfixExpression(operator string, left, right *big.Int) object.Object {
+	switch operator {
+	case "+":
+		return object.NewInteger(new(big.Int).Add(left, right))
+	case "-":
+		return object.NewInteger(new(big.Int).Sub(left, right))
+	case "*":
+		return object.NewInteger(new(big.Int).Mul(left, right))
+	case "/", "%":
+		if err := checkDivisor(operator, right.Sign() == 0); err != nil {
+			return err
+		}
+		// Quo і Rem відкидають дробову частину так само, як / і % для int64
+		if operator == "%" {
+			return object.NewInteger(new(big.Int).Rem(left, right))
+		}
+		return object.NewInteger(new(big.Int).Quo(left, right))
+	case "&":
+		return object.NewInteger(new(big.Int).And(left, right))
+	case "|":
+		return object.NewInteger(new(big.Int).Or(left, right))
+	case "^":
+		return object.NewInteger(new(big.Int).Xor(left, right))
+	case "<<", ">>":
+		return evalBigShift(operator, left, right)
+	case ">":
+		return nativeBoolToBooleanObject(left.Cmp(right) > 0)
+	case "<":
+		return nativeBoolToBooleanObject(left.Cmp(right) < 0)
+	case ">=":
+		return nativeBoolToBooleanObject(left.Cmp(right) >= 0)
+	case "<=":
+		return nativeBoolToBooleanObject(left.Cmp(right) <= 0)
+	case "==":
+		return nativeBoolToBooleanObject(left.Cmp(right) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(left.Cmp(right) != 0)
+	default:
+		return newError("unknown operator: %s %s %s", object.INTEGER_OBJ, operator, object.INTEGER_OBJ)
+	}
+}
+
+// maxBigShift обмежує зсув вліво в режимі ArithmeticBig, щоб один вираз
+// не створив число на гігабайти
+const maxBigShift = 1 << 20
+
+func evalBigShift(operator string, left, right *big.Int) object.Object {
+	if right.Sign() < 0 {
+		return newError("negative shift count: %s", right)
+	}
+	if operator == ">>" {
+		if !right.IsUint64() {
+			// всі значущі біти вже зсунуто, лишається знак
+			return object.NewInteger(big.NewInt(int64(min(left.Sign(), 0))))
+		}
+		return object.NewInteger(new(big.Int).Rsh(left, uint(right.Uint64())))
+	}
+	if !right.IsInt64() || right.Int64() > maxBigShift {
+		return newError("shift count too large: %s", right)
+	}
+	return object.NewInteger(new(big.Int).Lsh(left, uint(right.Int64())))
+}
+
+func isShift(operator string) bool {
+	return operator == "<<" || operator == ">>"
+}
+
+func checkDivisor(operator string, zero bool) *object.Error {
+	if !zero {
+		return nil
+	}
+	if operator == "%" {
+		return newError("modulo by zero")
+	}
+	if operator == "/" {
+		return newError("division by zero")
+	}
+	return nil
+}
+
+func bigValue(integer object.Object) *big.Int {
+	switch integer := integer.(type) {
+	case *object.Integer:
+		return big.NewInt(integer.Value)
+	case *object.BigInteger:
+		return integer.Value
+	}
+	return new(big.Int)
+}
+
+func evalFloatInfixExpression(operator string, left, right float64) object.Object {
+	switch operator {
+	case "+":
+		return &object.Float{Value: left + right}
+	case "-":
+		return &object.Float{Value: left - right}
+	case "*":
+		return &object.Float{Value: left * right}
+	case "/", "%":
+		// як і для цілих, замість Inf чи NaN - помилка
+		if err := checkDivisor(operator, right == 0); err != nil {
+			return err
+		}
+		if operator == "%" {
+			return &object.Float{Value: math.Mod(left, right)}
+		}
+		return &object.Float{Value: left / right}
+	case ">":
+		return nativeBoolToBooleanObject(left > right)
+	case "<":
+		return nativeBoolToBooleanObject(left < right)
+	case ">=":
+		return nativeBoolToBooleanObject(left >= right)
+	case "<=":
+		return nativeBoolToBooleanObject(left <= right)
+	case "==":
+		return nativeBoolToBooleanObject(left == right)
+	case "!=":
+		return nativeBoolToBooleanObject(left != right)
+	default:
+		return newError("unknown operator: %s %s %s", object.FLOAT_OBJ, operator, object.FLOAT_OBJ)
+	}
+}
@@ -0,0 +1,10 @@
+package evaluator
+
+import "interpreter/object"
+
+// RegisterBuiltin додає нативну функцію, яку скрипти бачать як ідентифікатор name,
+// якщо його не перекрито змінною в середовищі. Повторна реєстрація замінює попередню.
+// функція може повернути nil - скрипт отримає null
+func RegisterBuiltin(name string, fn object.BuiltinFunction) {
+	object.RegisterBuiltin(name, fn)
+}
@@ -0,0 +1,94 @@
+package evaluator
+
+import (
+	"fmt"
+	"interpreter/compiler"
+	"interpreter/lexer"
+	"interpreter/object"
+	"interpreter/parser"
+	"interpreter/vm"
+	"io"
+	"os"
+	"testing"
+)
+
+// кожен вхід testEval також компілюється і виконується у vm.
+// розбіжності між рушіями збираються тут і валять прогін у TestMain
+var crossCheckFailures []string
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if len(crossCheckFailures) > 0 {
+		fmt.Fprintf(os.Stderr, "evaluator and vm disagree on %d inputs:\n", len(crossCheckFailures))
+		for _, failure := range crossCheckFailures {
+			fmt.Fprintln(os.Stderr, failure)
+		}
+		code = 1
+	}
+	os.Exit(code)
+}
+
+func crossCheck(input string, expected object.Object) {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors) > 0 {
+		return
+	}
+
+	comp := compiler.New()
+	if err := comp.Compile(program); err != nil {
+		crossCheckFailures = append(crossCheckFailures, fmt.Sprintf("%q: compiler error: %s", input, err))
+		return
+	}
+
+	// puts не має друкувати вдруге
+	output := object.BuiltinOutput
+	object.BuiltinOutput = io.Discard
+	defer func() { object.BuiltinOutput = output }()
+
+	machine := vm.New(comp.Bytecode())
+	var got object.Object
+	if err := machine.Run(); err != nil {
+		rtErr, ok := err.(*object.Error)
+		if !ok {
+			crossCheckFailures = append(crossCheckFailures, fmt.Sprintf("%q: vm error: %s", input, err))
+			return
+		}
+		got = rtErr
+	} else {
+		got = machine.LastPoppedStackElem()
+	}
+
+	if !sameResult(expected, got) {
+		crossCheckFailures = append(crossCheckFailures,
+			fmt.Sprintf("%q: evaluator=%s, vm=%s", input, describe(expected), describe(got)))
+	}
+}
+
+func sameResult(evaluated, executed object.Object) bool {
+	if evaluated == nil {
+		evaluated = NULL
+	}
+
+	switch evaluated := evaluated.(type) {
+	case *object.Error:
+		err, ok := executed.(*object.Error)
+		if !ok || err.Message != evaluated.Message {
+			return false
+		}
+		// рушії вичерпують стек у різних місцях: evaluator - на виклику, vm - на будь-якому push
+		return err.Pos == evaluated.Pos || err.Message == "stack overflow"
+	case *object.Function:
+		_, ok := executed.(*object.Closure)
+		return ok
+	default:
+		return executed != nil && evaluated.Type() == executed.Type() && evaluated.Inspect() == executed.Inspect()
+	}
+}
+
+func describe(obj object.Object) string {
+	if obj == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("%s(%s)", obj.Type(), obj.Inspect())
+}
@@ -1,30 +1,146 @@
 package evaluator
 
 import (
-	"fmt"
+	"context"
 	"interpreter/ast"
 	"interpreter/object"
+	"math"
+	"math/big"
 	"reflect"
+	"strings"
 )
 
 var (
 	TRUE  = &object.Boolean{Value: true}
 	NULL  = &object.Null{}
 	FALSE = &object.Boolean{Value: false}
+
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
 )
 
+// DefaultMaxDepth - глибина викликів, якщо Options.MaxDepth не задано. Рекурсія без дна
+// завершується помилкою "stack overflow" замість переповнення стеку Go
+const DefaultMaxDepth = 1024
+
+// кожні cancelCheckInterval кроків перевіряється, чи не скасовано контекст
+const cancelCheckInterval = 1024
+
+// Options обмежує виконання. нуль у MaxSteps чи MaxObjects означає без обмеження
+type Options struct {
+	// MaxSteps - скільки вузлів дерева можна обчислити
+	MaxSteps int
+	// MaxDepth - максимальна глибина викликів функцій, 0 означає DefaultMaxDepth
+	MaxDepth int
+	// MaxObjects - скільки нових значень (чисел, рядків, масивів, хешів, функцій, скоупів) можна створити
+	MaxObjects int
+	// Arithmetic - що робити, коли ціле переповнює int64
+	Arithmetic ArithmeticMode
+}
+
+// state - лічильники одного запуску Eval
+type state struct {
+	ctx     context.Context
+	opts    Options
+	steps   int
+	depth   int
+	objects int
+}
+
+func newState(ctx context.Context, opts Options) *state {
+	if opts.MaxDepth == 0 {
+		opts.MaxDepth = DefaultMaxDepth
+	}
+	return &state{ctx: ctx, opts: opts}
+}
+
+// Eval обчислює вузол без обмежень, крім DefaultMaxDepth
 func Eval(node ast.Node, environment *object.Environment) object.Object {
+	return EvalContext(context.Background(), node, environment, Options{})
+}
+
+// EvalContext обчислює вузол з обмеженнями opts. Скасування ctx або вихід за обмеження
+// зупиняє виконання з помилкою "execution cancelled", "stack overflow",
+// "step limit exceeded" чи "object limit exceeded"
+func EvalContext(ctx context.Context, node ast.Node, environment *object.Environment, opts Options) object.Object {
+	s := newState(ctx, opts)
+	if err := s.ctx.Err(); err != nil {
+		return newError("execution cancelled: %s", err)
+	}
+	return s.eval(node, environment)
+}
+
+func (s *state) eval(node ast.Node, environment *object.Environment) object.Object {
+	var result object.Object
+	if err := s.step(); err != nil {
+		result = err
+	} else {
+		result = s.evalNode(node, environment)
+	}
+
+	// помилка отримує позицію найглибшого вузла, де вона виникла;
+	// зовнішні вузли її вже не перезаписують. позиція ставиться на копію: вбудована
+	// функція хоста може повертати спільне значення помилки
+	if err, ok := result.(*object.Error); ok && !err.Pos.IsValid() && node != nil {
+		positioned := *err
+		positioned.Pos = node.Pos()
+		result = &positioned
+	}
+
+	return result
+}
+
+func (s *state) step() *object.Error {
+	s.steps++
+	if s.opts.MaxSteps > 0 && s.steps > s.opts.MaxSteps {
+		return newError("step limit exceeded: %d steps", s.opts.MaxSteps)
+	}
+	if s.steps%cancelCheckInterval == 0 {
+		if err := s.ctx.Err(); err != nil {
+			return newError("execution cancelled: %s", err)
+		}
+	}
+	return nil
+}
+
+// track рахує щойно створене значення. TRUE, FALSE, NULL і помилки не рахуються
+func (s *state) track(obj object.Object) object.Object {
+	switch obj {
+	case nil, TRUE, FALSE, NULL:
+		return obj
+	}
+	if isError(obj) {
+		return obj
+	}
+	if err := s.allocate(); err != nil {
+		return err
+	}
+	return obj
+}
+
+func (s *state) allocate() *object.Error {
+	s.objects++
+	if s.opts.MaxObjects > 0 && s.objects > s.opts.MaxObjects {
+		return newError("object limit exceeded: %d objects", s.opts.MaxObjects)
+	}
+	return nil
+}
 
+func (s *state) evalNode(node ast.Node, environment *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
-		return evalProgram(node, environment)
+		return s.evalProgram(node, environment)
 	case *ast.ExpressionStatement:
-		return Eval(node.Expression, environment)
+		return s.eval(node.Expression, environment)
 	case *ast.IntegerLiteral:
-		return &object.Integer{Value: node.Value}
+		return s.track(&object.Integer{Value: node.Value})
+	case *ast.FloatLiteral:
+		return s.track(&object.Float{Value: node.Value})
+	case *ast.StringLiteral:
+		return s.track(&object.String{Value: node.Value})
 	case *ast.ReturnStatement:
-		val := Eval(node.ReturnValue, environment)
-		if isError(val) {
+		val := s.eval(node.ReturnValue, environment)
+		if isAbrupt(val) {
 			return val
 		}
 		return &object.ReturnValue{Value: val}
@@ -34,62 +150,111 @@ func Eval(node ast.Node, environment *object.Environment) object.Object {
 		// приклад !!5 буде right FALSE
 		// оскільки ми підемо в default в evalBangOperatorExpression
 		// і після заходу в evalPrefix буде в нас true
-		right := Eval(node.Right, environment)
-		if isError(right) {
+		right := s.eval(node.Right, environment)
+		if isAbrupt(right) {
 			return right
 		}
-		return evalPrefix(node.Operator, right)
+		return s.track(s.evalPrefix(node.Operator, right))
 	case *ast.InfixExpression:
-		left := Eval(node.Left, environment)
-		if isError(left) {
+		if node.Operator == "&&" || node.Operator == "||" {
+			return s.evalLogicalExpression(node, environment)
+		}
+		if node.Operator == "??" {
+			return s.evalCoalesceExpression(node, environment)
+		}
+		left := s.eval(node.Left, environment)
+		if isAbrupt(left) {
 			return left
 		}
-		right := Eval(node.Right, environment)
-		if isError(right) {
+		right := s.eval(node.Right, environment)
+		if isAbrupt(right) {
 			return right
 		}
-		return evalInfixExpression(left, right, node.Operator)
+		return s.track(s.evalInfixExpression(left, right, node.Operator))
+	case *ast.AssignExpression:
+		return s.evalAssignExpression(node, environment)
 	case *ast.IfExpression:
-		return evalIfExpression(node, environment)
+		return s.evalIfExpression(node, environment)
+	case *ast.ConditionalExpression:
+		return s.evalConditionalExpression(node, environment)
 	case *ast.BlockStatement:
-		return evalBlockStatements(node.Statements, environment)
+		return s.evalBlockStatements(node.Statements, environment)
+	case *ast.WhileStatement:
+		return s.evalWhileStatement(node, environment)
+	case *ast.ForStatement:
+		return s.evalForStatement(node, environment)
+	case *ast.BreakStatement:
+		return BREAK
+	case *ast.ContinueStatement:
+		return CONTINUE
 	case *ast.LetStatement:
-		val := Eval(node.Value, environment)
-		if isError(val) {
+		val := s.eval(node.Value, environment)
+		if isAbrupt(val) {
 			return val
 		}
 		//тут ми в середовище(наший сторедж) будемо зберігати значення під назвою змінної 'let a = b'  (map (key=a, val=b))
-		environment.Set(node.Name.Value, val)
+		if node.IsConst() {
+			environment.SetConst(node.Name.Value, val)
+		} else {
+			environment.Set(node.Name.Value, val)
+		}
 	case *ast.Identifier:
 		return evalIdentifier(node, environment)
 
 	case *ast.FunctionLiteral:
 		params := node.Parameters
-		return &object.Function{
+		return s.track(&object.Function{
 			Parameters: params,
 			Body:       &node.Body,
 			Env:        environment,
-		}
+		})
 	case *ast.CallExpression:
-		function := Eval(node.Function, environment)
-		if isError(function) {
+		function := s.eval(node.Function, environment)
+		if isAbrupt(function) {
 			return function
 		}
-		args := evalExpressions(node.Arguments, environment)
-		if len(args) == 1 && isError(args[0]) {
+		if node.Optional && function == NULL {
+			return NULL
+		}
+		args := s.evalExpressions(node.Arguments, environment)
+		if len(args) == 1 && isAbrupt(args[0]) {
 			return args[0]
 		}
+		return s.applyFunction(function, args)
+	case *ast.ArrayLiteral:
+		elements := s.evalExpressions(node.Elements, environment)
+		if len(elements) == 1 && isAbrupt(elements[0]) {
+			return elements[0]
+		}
+		return s.track(&object.Array{Elements: elements})
+	case *ast.IndexExpression:
+		left := s.eval(node.Left, environment)
+		if isAbrupt(left) {
+			return left
+		}
+		if node.Optional && left == NULL {
+			return NULL
+		}
+		index := s.eval(node.Index, environment)
+		if isAbrupt(index) {
+			return index
+		}
+		return evalIndexExpression(left, index)
+	case *ast.HashLiteral:
+		return s.track(s.evalHashLiteral(node, environment))
+	case *ast.BadStatement, *ast.BadExpression:
+		return newError("invalid syntax")
 	}
 
 	return nil
 }
 
-func evalExpressions(expressions []ast.Expression, environment *object.Environment) []object.Object {
+func (s *state) evalExpressions(expressions []ast.Expression, environment *object.Environment) []object.Object {
 	var result []object.Object
 
 	for _, exp := range expressions {
-		argRes := Eval(exp, environment)
-		if isError(argRes) {
+		argRes := s.eval(exp, environment)
+		if isAbrupt(argRes) {
 			return []object.Object{argRes}
 		}
 		result = append(result, argRes)
@@ -97,28 +262,325 @@ func evalExpressions(expressions []ast.Expression, environment *object.Environme
 	return result
 }
 
+// ApplyFunction викликає функцію скрипта або вбудовану функцію з уже обчисленими аргументами.
+// помилка повертається як *object.Error
+func ApplyFunction(fn object.Object, args []object.Object) object.Object {
+	return ApplyFunctionContext(context.Background(), fn, args, Options{})
+}
+
+// ApplyFunctionContext - ApplyFunction з обмеженнями, як у EvalContext
+func ApplyFunctionContext(ctx context.Context, fn object.Object, args []object.Object, opts Options) object.Object {
+	s := newState(ctx, opts)
+	if err := s.ctx.Err(); err != nil {
+		return newError("execution cancelled: %s", err)
+	}
+	return s.applyFunction(fn, args)
+}
+
+func (s *state) applyFunction(fn object.Object, args []object.Object) object.Object {
+	switch function := fn.(type) {
+	case *object.Function:
+		if len(args) != len(function.Parameters) {
+			return newError("wrong number of arguments: want=%d, got=%d", len(function.Parameters), len(args))
+		}
+
+		if s.depth >= s.opts.MaxDepth {
+			return newError("stack overflow")
+		}
+		if err := s.allocate(); err != nil {
+			return err
+		}
+
+		s.depth++
+		defer func() { s.depth-- }()
+
+		extendedEnv := extendFunctionEnv(function, args)
+		evaluated := s.eval(function.Body, extendedEnv)
+		return unwrapReturnValue(evaluated)
+	case *object.Builtin:
+		var result object.Object
+		if function.CallerFn != nil {
+			result = function.CallerFn(s.applyFunction, args...)
+		} else {
+			result = function.Fn(args...)
+		}
+		if result != nil {
+			return s.track(result)
+		}
+		return NULL
+	default:
+		return newError("not a function: %s", fn.Type())
+	}
+}
+
+// кожен виклик отримує свій скоуп, зовнішній скоуп - це середовище де функцію було оголошено (замикання)
+func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
+	env := object.NewEnclosingEnvironment(fn.Env)
+
+	for i, param := range fn.Parameters {
+		env.Set(param.Value, args[i])
+	}
+
+	return env
+}
+
+func unwrapReturnValue(obj object.Object) object.Object {
+	if returnValue, ok := obj.(*object.ReturnValue); ok {
+		return returnValue.Value
+	}
+	return obj
+}
+
 func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
-	val, ok := env.Get(node.Value)
+	if val, ok := env.Get(node.Value); ok {
+		return val
+	}
+
+	if builtin, ok := object.LookupBuiltin(node.Value); ok {
+		return builtin
+	}
+
+	return newError("identifier not found: %s", node.Value)
+}
+
+func evalIndexExpression(left, index object.Object) object.Object {
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		integer, ok := index.(*object.Integer)
+		if !ok {
+			// *object.BigInteger завжди за межами масиву
+			return NULL
+		}
+		return evalArrayIndexExpression(left.(*object.Array), integer)
+	case left.Type() == object.HASH_OBJ:
+		return evalHashIndexExpression(left.(*object.Hash), index)
+	default:
+		return newError("index operator not supported: %s[%s]", left.Type(), index.Type())
+	}
+}
+
+// evalAssignExpression змінює існуючу змінну або елемент колекції і повертає нове значення.
+// у складеному присвоєнні x += v старе значення читається до обчислення v
+func (s *state) evalAssignExpression(node *ast.AssignExpression, environment *object.Environment) object.Object {
+	operator := strings.TrimSuffix(node.Operator, "=")
+
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		var current object.Object
+		if operator != "" {
+			current = s.eval(target, environment)
+			if isAbrupt(current) {
+				return current
+			}
+		}
+		value := s.eval(node.Value, environment)
+		if isAbrupt(value) {
+			return value
+		}
+		if current != nil {
+			value = s.track(s.evalInfixExpression(current, value, operator))
+			if isError(value) {
+				return value
+			}
+		}
+		return environment.Assign(target.Value, value)
+
+	case *ast.IndexExpression:
+		left := s.eval(target.Left, environment)
+		if isAbrupt(left) {
+			return left
+		}
+		index := s.eval(target.Index, environment)
+		if isAbrupt(index) {
+			return index
+		}
+		value := s.eval(node.Value, environment)
+		if isAbrupt(value) {
+			return value
+		}
+		if operator != "" {
+			current := evalIndexExpression(left, index)
+			if isError(current) {
+				return current
+			}
+			value = s.track(s.evalInfixExpression(current, value, operator))
+			if isError(value) {
+				return value
+			}
+		}
+		return object.SetIndex(left, index, value)
+	}
+
+	return newError("invalid assignment target: %s", node.Target.String())
+}
+
+// від'ємний індекс рахується з кінця: arr[-1] - останній елемент.
+// індекс за межами масиву дає NULL
+func evalArrayIndexExpression(array *object.Array, index *object.Integer) object.Object {
+	idx := index.Value
+	length := int64(len(array.Elements))
+
+	if idx < 0 {
+		idx += length
+	}
+
+	if idx < 0 || idx >= length {
+		return NULL
+	}
+
+	return array.Elements[idx]
+}
+
+func (s *state) evalHashLiteral(node *ast.HashLiteral, environment *object.Environment) object.Object {
+	hash := object.NewHash()
+
+	for _, pair := range node.Pairs {
+		key := s.eval(pair.Key, environment)
+		if isAbrupt(key) {
+			return key
+		}
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+
+		value := s.eval(pair.Value, environment)
+		if isAbrupt(value) {
+			return value
+		}
+
+		hash.Set(hashKey, value)
+	}
+
+	return hash
+}
+
+// відсутній ключ дає NULL
+func evalHashIndexExpression(hash *object.Hash, index object.Object) object.Object {
+	key, ok := index.(object.Hashable)
+	if !ok {
+		return newError("unusable as hash key: %s", index.Type())
+	}
+
+	value, ok := hash.Get(key)
 	if !ok {
-		return newError("identifier not found: %s", node.Value)
+		return NULL
+	}
+
+	return value
+}
+
+// && і || не обчислюють правий операнд, якщо результат відомий з лівого, і завжди дають BOOLEAN
+func (s *state) evalLogicalExpression(node *ast.InfixExpression, environment *object.Environment) object.Object {
+	left := s.eval(node.Left, environment)
+	if isAbrupt(left) {
+		return left
+	}
+	if node.Operator == "&&" && !isTruthy(left) {
+		return FALSE
+	}
+	if node.Operator == "||" && isTruthy(left) {
+		return TRUE
 	}
-	return val
+
+	right := s.eval(node.Right, environment)
+	if isAbrupt(right) {
+		return right
+	}
+	return nativeBoolToBooleanObject(isTruthy(right))
+}
+
+// a ?? b обчислює b, тільки якщо a - NULL. false, 0 і "" лишаються як є
+func (s *state) evalCoalesceExpression(node *ast.InfixExpression, environment *object.Environment) object.Object {
+	left := s.eval(node.Left, environment)
+	if left != NULL {
+		return left
+	}
+	return s.eval(node.Right, environment)
 }
 
-func evalIfExpression(ie *ast.IfExpression, environment *object.Environment) object.Object {
-	cond := Eval(ie.Condition, environment)
-	if isError(cond) {
+func (s *state) evalConditionalExpression(ce *ast.ConditionalExpression, environment *object.Environment) object.Object {
+	cond := s.eval(ce.Condition, environment)
+	if isAbrupt(cond) {
 		return cond
 	}
 	if isTruthy(cond) {
-		return Eval(ie.Consequence, environment)
+		return s.eval(ce.Consequence, environment)
+	}
+	return s.eval(ce.Alternative, environment)
+}
+
+func (s *state) evalIfExpression(ie *ast.IfExpression, environment *object.Environment) object.Object {
+	cond := s.eval(ie.Condition, environment)
+	if isAbrupt(cond) {
+		return cond
+	}
+	if isTruthy(cond) {
+		return s.eval(ie.Consequence, environment)
 	} else if ie.Alternative != nil {
-		return Eval(ie.Alternative, environment)
+		return s.eval(ie.Alternative, environment)
 	} else {
 		return NULL
 	}
 }
 
+// evalWhileStatement виконує тіло в тому ж середовищі, що й цикл, як і блоки if
+func (s *state) evalWhileStatement(ws *ast.WhileStatement, environment *object.Environment) object.Object {
+	for {
+		cond := s.eval(ws.Condition, environment)
+		if isAbrupt(cond) {
+			return cond
+		}
+		if !isTruthy(cond) {
+			return nil
+		}
+
+		result := s.eval(ws.Body, environment)
+		if result == BREAK {
+			return nil
+		}
+		if isError(result) || (result != nil && result.Type() == object.RETURN_VALUE_OBJ) {
+			return result
+		}
+	}
+}
+
+// evalForStatement присвоює змінній циклу кожен елемент ітерованого значення
+func (s *state) evalForStatement(fs *ast.ForStatement, environment *object.Environment) object.Object {
+	iterable := s.eval(fs.Iterable, environment)
+	if isAbrupt(iterable) {
+		return iterable
+	}
+	iterator, err := object.NewIterator(iterable)
+	if err != nil {
+		return err
+	}
+
+	for {
+		value, ok := iterator.Next()
+		if !ok {
+			return nil
+		}
+		// рядок і діапазон створюють нові значення, елементи масиву й ключі хешу - ні
+		if iterable.Type() == object.STRING_OBJ || iterable.Type() == object.RANGE_OBJ {
+			value = s.track(value)
+			if isError(value) {
+				return value
+			}
+		}
+		environment.Set(fs.Variable.Value, value)
+
+		result := s.eval(fs.Body, environment)
+		if result == BREAK {
+			return nil
+		}
+		if isError(result) || (result != nil && result.Type() == object.RETURN_VALUE_OBJ) {
+			return result
+		}
+	}
+}
+
 func isTruthy(cond object.Object) bool {
 	switch cond {
 	case NULL:
@@ -132,10 +594,17 @@ func isTruthy(cond object.Object) bool {
 	}
 }
 
-func evalInfixExpression(left object.Object, right object.Object, operator string) object.Object {
+func (s *state) evalInfixExpression(left object.Object, right object.Object, operator string) object.Object {
 	switch {
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
-		return evalIntegerInfixExpression(operator, left.(*object.Integer), right.(*object.Integer))
+		return s.evalIntegerInfixExpression(operator, left, right)
+	case object.IsNumber(left) && object.IsNumber(right):
+		// ціле з дробовим дає дробове
+		l, _ := object.FloatValue(left)
+		r, _ := object.FloatValue(right)
+		return evalFloatInfixExpression(operator, l, r)
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return evalStringInfixExpression(operator, left.(*object.String), right.(*object.String))
 	case operator == "==":
 		return nativeBoolToBooleanObject(left == right)
 	case operator == "!=":
@@ -147,52 +616,117 @@ func evalInfixExpression(left object.Object, right object.Object, operator strin
 	}
 }
 
-func evalIntegerInfixExpression(operator string, left, right *object.Integer) object.Object {
-	return processIntegerInfixExpression(left, right, operator)
+// в режимі ArithmeticBig операнди, що вже великі або переповнюють int64, рахуються через math/big
+func (s *state) evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
+	l, lok := left.(*object.Integer)
+	r, rok := right.(*object.Integer)
+	if lok && rok {
+		result, ok := processIntegerInfixExpression(l, r, operator)
+		if ok {
+			return result
+		}
+		if s.opts.Arithmetic != ArithmeticBig {
+			return newError("integer overflow: %d %s %d", l.Value, operator, r.Value)
+		}
+	}
+	return evalBigIntegerInfixExpression(operator, bigValue(left), bigValue(right))
 }
 
-func processIntegerInfixExpression(left, right *object.Integer, operator string) object.Object {
+// ok=false означає, що результат не влазить в int64
+func processIntegerInfixExpression(left, right *object.Integer, operator string) (object.Object, bool) {
 	switch operator {
-	case "*":
-		return &object.Integer{Value: left.Value * right.Value}
-	case "-":
-		return &object.Integer{Value: left.Value - right.Value}
-	case "/":
-		return &object.Integer{Value: left.Value / right.Value}
-	case "+":
-		return &object.Integer{Value: left.Value + right.Value}
+	case "+", "-", "*", "/", "%", "&", "|", "^", "<<", ">>":
+		if err := checkDivisor(operator, right.Value == 0); err != nil {
+			return err, true
+		}
+		if isShift(operator) && right.Value < 0 {
+			return newError("negative shift count: %d", right.Value), true
+		}
+		result, ok := object.IntegerOperation(operator, left.Value, right.Value)
+		if !ok {
+			return nil, false
+		}
+		return &object.Integer{Value: result}, true
 	case ">":
-		return nativeBoolToBooleanObject(left.Value > right.Value)
+		return nativeBoolToBooleanObject(left.Value > right.Value), true
+	case "==":
+		return nativeBoolToBooleanObject(left.Value == right.Value), true
+	case "!=":
+		return nativeBoolToBooleanObject(left.Value != right.Value), true
+	case "<":
+		return nativeBoolToBooleanObject(left.Value < right.Value), true
+	case ">=":
+		return nativeBoolToBooleanObject(left.Value >= right.Value), true
+	case "<=":
+		return nativeBoolToBooleanObject(left.Value <= right.Value), true
+	default:
+		return newError("unknown operator: %s %s %s", reflect.TypeOf(left), operator, reflect.TypeOf(right)), true
+	}
+}
+
+func evalStringInfixExpression(operator string, left, right *object.String) object.Object {
+	switch operator {
+	case "+":
+		return &object.String{Value: left.Value + right.Value}
 	case "==":
 		return nativeBoolToBooleanObject(left.Value == right.Value)
 	case "!=":
 		return nativeBoolToBooleanObject(left.Value != right.Value)
 	case "<":
 		return nativeBoolToBooleanObject(left.Value < right.Value)
+	case ">":
+		return nativeBoolToBooleanObject(left.Value > right.Value)
+	case "<=":
+		return nativeBoolToBooleanObject(left.Value <= right.Value)
+	case ">=":
+		return nativeBoolToBooleanObject(left.Value >= right.Value)
 	default:
-		return newError("unknown operator: %s %s %s", reflect.TypeOf(left), operator, reflect.TypeOf(right))
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
-func evalPrefix(operator string, right object.Object) object.Object {
+func (s *state) evalPrefix(operator string, right object.Object) object.Object {
 	switch operator {
 	case "!":
 		return evalBangOperatorExpression(right)
 	case "-":
-		return evalMinusOperatorExpression(right)
+		return s.evalMinusOperatorExpression(right)
+	case "~":
+		return evalBitwiseNotOperatorExpression(right)
 	default:
 		return newError("unknown operator: %s%s", operator, right.Type())
 	}
 }
 
-func evalMinusOperatorExpression(right object.Object) object.Object {
+func (s *state) evalMinusOperatorExpression(right object.Object) object.Object {
+	if float, ok := right.(*object.Float); ok {
+		return &object.Float{Value: -float.Value}
+	}
 	if right.Type() != object.INTEGER_OBJ {
 		return newError("unknown operator: -%s", right.Type())
 	}
 
-	value := right.(*object.Integer).Value
+	if integer, ok := right.(*object.Integer); ok {
+		if integer.Value != math.MinInt64 {
+			return &object.Integer{Value: -integer.Value}
+		}
+		if s.opts.Arithmetic != ArithmeticBig {
+			return newError("integer overflow: -(%d)", integer.Value)
+		}
+	}
+	return object.NewInteger(new(big.Int).Neg(bigValue(right)))
+}
 
-	return &object.Integer{Value: -value}
+// ~x - інверсія всіх бітів, тобто -x - 1, тому переповнення не буває
+func evalBitwiseNotOperatorExpression(right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Integer:
+		return &object.Integer{Value: ^right.Value}
+	case *object.BigInteger:
+		return object.NewInteger(new(big.Int).Not(right.Value))
+	default:
+		return newError("unknown operator: ~%s", right.Type())
+	}
 }
 
 func evalBangOperatorExpression(right object.Object) object.Object {
@@ -215,17 +749,17 @@ func nativeBoolToBooleanObject(b bool) *object.Boolean {
 	return FALSE
 }
 
-func evalProgram(program *ast.Program, env *object.Environment) object.Object {
+func (s *state) evalProgram(program *ast.Program, env *object.Environment) object.Object {
 
-	return evalStatements(program.Statements, env)
+	return s.evalStatements(program.Statements, env)
 }
 
-func evalBlockStatements(statements []ast.Statement, environment *object.Environment) object.Object {
+func (s *state) evalBlockStatements(statements []ast.Statement, environment *object.Environment) object.Object {
 	var result object.Object
 
 	for _, stmt := range statements {
-		result = Eval(stmt, environment)
-		if result.Type() == object.RETURN_VALUE_OBJ || result.Type() == object.ERROR_OBJ {
+		result = s.eval(stmt, environment)
+		if isAbrupt(result) {
 			return result
 		}
 	}
@@ -240,11 +774,24 @@ func isError(obj object.Object) bool {
 	return false
 }
 
-func evalStatements(statements []ast.Statement, env *object.Environment) object.Object {
+// isAbrupt - чи обриває obj обчислення: помилка, return, break або continue.
+// такий результат операнда передається далі замість значення, як і помилка
+func isAbrupt(obj object.Object) bool {
+	if obj == nil {
+		return false
+	}
+	switch obj.Type() {
+	case object.ERROR_OBJ, object.RETURN_VALUE_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
+		return true
+	}
+	return false
+}
+
+func (s *state) evalStatements(statements []ast.Statement, env *object.Environment) object.Object {
 	var result object.Object
 
 	for _, stmt := range statements {
-		result = Eval(stmt, env)
+		result = s.eval(stmt, env)
 
 		switch result := result.(type) {
 		case *object.ReturnValue:
@@ -258,5 +805,5 @@ func evalStatements(statements []ast.Statement, env *object.Environment) object.
 }
 
 func newError(format string, a ...any) *object.Error {
-	return &object.Error{Message: fmt.Sprintf(format, a...)}
+	return object.NewError(format, a...)
 }
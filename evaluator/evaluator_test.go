@@ -1,10 +1,15 @@
 package evaluator
 
 import (
+	"bytes"
+	"context"
 	"interpreter/lexer"
 	"interpreter/object"
 	"interpreter/parser"
+	"math"
+	"os"
 	"testing"
+	"time"
 )
 
 func TestBangOperator(t *testing.T) {
@@ -34,6 +39,7 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{"10", 10},
 		{"-10", -10},
 		{"-5", -5},
+		{"0xFF + 0o10 + 0b11 + 1_000", 1266},
 		{"5 + 5 + 5 + 5 - 10", 10},
 		{"2 * 2 * 2 * 2 * 2", 32},
 		{"-50 + 100 + -50", 0},
@@ -148,7 +154,9 @@ func testEval(input string) object.Object {
 	p := parser.New(l)
 	program := p.ParseProgram()
 	env := object.NewEnvironment()
-	return Eval(program, env)
+	result := Eval(program, env)
+	crossCheck(input, result)
+	return result
 }
 
 func TestReturnStatements(t *testing.T) {
@@ -220,6 +228,26 @@ func TestErrorHandling(t *testing.T) {
 			"foobar",
 			"identifier not found: foobar",
 		},
+		{
+			`"Hello" - "World"`,
+			"unknown operator: STRING - STRING",
+		},
+		{
+			`"Hello" + 1`,
+			"type mismatch: STRING + INTEGER",
+		},
+		{
+			`{"name": "Monkey"}[fn(x) { x }];`,
+			"unusable as hash key: FUNCTION",
+		},
+		{
+			`{fn(x) { x }: 1};`,
+			"unusable as hash key: FUNCTION",
+		},
+		{
+			"let x 5; x",
+			"invalid syntax",
+		},
 	}
 
 	for _, tt := range tests {
@@ -245,6 +273,8 @@ func TestLetStatements(t *testing.T) {
 		{"let a = 5 * 5; a;", 25},
 		{"let a = 5; let b = a; b;", 5},
 		{"let a = 5; let b = a; let c = a + b + 5; c;", 15},
+		{"let ціна = 10; let x1 = 2; ціна * x1", 20},
+		{"/// doc\nlet a = 5; // five\n/* a /* nested */ comment */ a * 2 /**/;", 10},
 	}
 
 	for _, tt := range tests {
@@ -293,3 +323,905 @@ func TestFunctionApplication(t *testing.T) {
 		testIntegerObject(t, testEval(tt.input), tt.expected)
 	}
 }
+
+func TestFunctionApplicationErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"let add = fn(x, y) { x + y; }; add(1);", "wrong number of arguments: want=2, got=1"},
+		{"let id = fn(x) { x; }; id(1, 2);", "wrong number of arguments: want=1, got=2"},
+		{"let a = 5; a(1);", "not a function: INTEGER"},
+	}
+
+	for _, tt := range tests {
+		eval := testEval(tt.input)
+		err, ok := eval.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", eval, eval)
+			continue
+		}
+		if err.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, err.Message)
+		}
+	}
+}
+
+func TestClosures(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`
+		let newAdder = fn(x) {
+			fn(y) { x + y };
+		};
+		let addTwo = newAdder(2);
+		addTwo(2);`, 4},
+		{`
+		let x = 10;
+		let f = fn(x) { x * 2 };
+		f(3) + x;`, 16},
+		{`
+		let apply = fn(f, a, b) { f(a, b) };
+		let add = fn(a, b) { a + b };
+		apply(add, 3, 4);`, 7},
+		{`
+		let counter = fn(n) {
+			if (n > 0) { return counter(n - 1) + 1; }
+			0;
+		};
+		counter(5);`, 5},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestStringLiteral(t *testing.T) {
+	input := `"Hello World!"`
+
+	evaluated := testEval(input)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if str.Value != "Hello World!" {
+		t.Errorf("String has wrong value. got=%q", str.Value)
+	}
+}
+
+func TestStringConcatenation(t *testing.T) {
+	input := `"Hello" + " " + "World!\n"`
+
+	evaluated := testEval(input)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if str.Value != "Hello World!\n" {
+		t.Errorf("String has wrong value. got=%q", str.Value)
+	}
+}
+
+func TestStringComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`"a" == "a"`, true},
+		{`"a" == "b"`, false},
+		{`"a" != "b"`, true},
+		{`"a" < "b"`, true},
+		{`"b" > "a"`, true},
+		{`"abc" < "ab"`, false},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestArrayLiterals(t *testing.T) {
+	input := "[1, 2 * 2, 3 + 3]"
+
+	evaluated := testEval(input)
+	result, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if len(result.Elements) != 3 {
+		t.Fatalf("array has wrong num of elements. got=%d", len(result.Elements))
+	}
+
+	testIntegerObject(t, result.Elements[0], 1)
+	testIntegerObject(t, result.Elements[1], 4)
+	testIntegerObject(t, result.Elements[2], 6)
+}
+
+func TestArrayIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"[1, 2, 3][0]", 1},
+		{"[1, 2, 3][1]", 2},
+		{"[1, 2, 3][2]", 3},
+		{"let i = 0; [1][i];", 1},
+		{"[1, 2, 3][1 + 1];", 3},
+		{"let myArray = [1, 2, 3]; myArray[2];", 3},
+		{"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];", 6},
+		{"let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]", 2},
+		{"[1, 2, 3][3]", nil},
+		{"[1, 2, 3][-1]", 3},
+		{"[1, 2, 3][-3]", 1},
+		{"[1, 2, 3][-4]", nil},
+		{"[fn(x) { x * 2 }][0](4)", 8},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestArrayBuiltinFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`len("")`, 0},
+		{`len("four")`, 4},
+		{`len("привіт")`, 6},
+		{`len([1, 2, 3])`, 3},
+		{`len([])`, 0},
+		{`len(1)`, "argument to `len` not supported, got INTEGER"},
+		{`len("one", "two")`, "wrong number of arguments. got=2, want=1"},
+		// ім'я шукається під час виконання: пізніший let затіняє вбудовану функцію
+		{`let f = fn() { len }; let len = 5; f()`, 5},
+		{`first([1, 2, 3])`, 1},
+		{`first([])`, nil},
+		{`first(1)`, "argument to `first` must be ARRAY, got INTEGER"},
+		{`last([1, 2, 3])`, 3},
+		{`last([])`, nil},
+		{`rest([1, 2, 3])`, []int{2, 3}},
+		{`rest([])`, nil},
+		{`push([], 1)`, []int{1}},
+		{`push(1, 1)`, "argument to `push` must be ARRAY, got INTEGER"},
+		{`let a = [1, 2]; let b = push(a, 3); len(a)`, 2},
+		{`let a = [1, 2]; let b = rest(a); a`, []int{1, 2}},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		case []int:
+			array, ok := evaluated.(*object.Array)
+			if !ok {
+				t.Errorf("obj not Array. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if len(array.Elements) != len(expected) {
+				t.Errorf("wrong num of elements. want=%d, got=%d", len(expected), len(array.Elements))
+				continue
+			}
+			for i, expectedElem := range expected {
+				testIntegerObject(t, array.Elements[i], int64(expectedElem))
+			}
+		}
+	}
+}
+
+func TestHashLiterals(t *testing.T) {
+	input := `let two = "two";
+	{
+		"one": 10 - 9,
+		two: 1 + 1,
+		"thr" + "ee": 6 / 2,
+		4: 4,
+		true: 5,
+		false: 6
+	}`
+
+	evaluated := testEval(input)
+	result, ok := evaluated.(*object.Hash)
+	if !ok {
+		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	expected := map[object.HashKey]int64{
+		(&object.String{Value: "one"}).HashKey():   1,
+		(&object.String{Value: "two"}).HashKey():   2,
+		(&object.String{Value: "three"}).HashKey(): 3,
+		(&object.Integer{Value: 4}).HashKey():      4,
+		TRUE.HashKey():                             5,
+		FALSE.HashKey():                            6,
+	}
+
+	if len(result.Pairs) != len(expected) {
+		t.Fatalf("Hash has wrong num of pairs. got=%d", len(result.Pairs))
+	}
+
+	for expectedKey, expectedValue := range expected {
+		pair, ok := result.Pairs[expectedKey]
+		if !ok {
+			t.Errorf("no pair for given key in Pairs")
+		}
+		testIntegerObject(t, pair.Value, expectedValue)
+	}
+
+	expectedInspect := `{one: 1, two: 2, three: 3, 4: 4, true: 5, false: 6}`
+	if result.Inspect() != expectedInspect {
+		t.Errorf("wrong Inspect. expected=%q, got=%q", expectedInspect, result.Inspect())
+	}
+}
+
+func TestHashIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`{"foo": 5}["foo"]`, 5},
+		{`{"foo": 5}["bar"]`, nil},
+		{`let key = "foo"; {"foo": 5}[key]`, 5},
+		{`{}["foo"]`, nil},
+		{`{5: 5}[5]`, 5},
+		{`{true: 5}[true]`, 5},
+		{`{false: 5}[false]`, 5},
+		{`{"a": 1, "a": 2}["a"]`, 2},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestHashBuiltinFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`keys({"a": 1, "b": 2})`, `[a, b]`},
+		{`values({"a": 1, "b": 2})`, `[1, 2]`},
+		{`keys({})`, `[]`},
+		{`delete({"a": 1, "b": 2}, "a")`, `{b: 2}`},
+		{`delete({"a": 1}, "c")`, `{a: 1}`},
+		{`let h = {"a": 1}; let d = delete(h, "a"); h`, `{a: 1}`},
+		{`len({"a": 1, "b": 2})`, `2`},
+		{`keys([1])`, "ERROR: 1:5: argument to `keys` must be HASH, got ARRAY"},
+		{`delete({"a": 1}, fn(x) { x })`, "ERROR: 1:7: unusable as hash key: FUNCTION"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("input %q: expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestRegisterBuiltin(t *testing.T) {
+	RegisterBuiltin("double", func(args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments. got=%d, want=1", len(args))
+		}
+		integer, ok := args[0].(*object.Integer)
+		if !ok {
+			return newError("argument to `double` must be INTEGER, got %s", args[0].Type())
+		}
+		return &object.Integer{Value: integer.Value * 2}
+	})
+
+	testIntegerObject(t, testEval("double(21)"), 42)
+	testIntegerObject(t, testEval("let f = fn(g) { g(4) }; f(double)"), 8)
+	// змінна в середовищі перекриває вбудовану функцію
+	testIntegerObject(t, testEval("let double = fn(x) { x }; double(3)"), 3)
+
+	if inspect := testEval("double").Inspect(); inspect != "builtin function double" {
+		t.Errorf("wrong Inspect. got=%q", inspect)
+	}
+}
+
+func TestSharedBuiltinError(t *testing.T) {
+	shared := newError("always fails")
+	RegisterBuiltin("fail", func(args ...object.Object) object.Object {
+		return shared
+	})
+
+	evaluated := testEval("1;\n fail()")
+	if inspect := evaluated.Inspect(); inspect != "ERROR: 2:6: always fails" {
+		t.Errorf("wrong error. got=%q", inspect)
+	}
+	if shared.Pos.IsValid() {
+		t.Errorf("shared error was mutated: Pos=%s", shared.Pos)
+	}
+}
+
+func TestPutsBuiltin(t *testing.T) {
+	var buf bytes.Buffer
+	object.BuiltinOutput = &buf
+	defer func() { object.BuiltinOutput = os.Stdout }()
+
+	evaluated := testEval(`puts("hello", 1, [true])`)
+	testNullObject(t, evaluated)
+
+	expected := "hello\n1\n[true]\n"
+	if buf.String() != expected {
+		t.Errorf("wrong output. expected=%q, got=%q", expected, buf.String())
+	}
+}
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedPos string
+	}{
+		{"5 + true;", "1:3"},
+		{"let a = 1;\nlet b = a + true;", "2:11"},
+		{"-true", "1:1"},
+		{"let f = fn(x) {\n  x + missing\n};\nf(1);", "2:7"},
+		{"len(1)", "1:4"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		err, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if err.Pos.String() != tt.expectedPos {
+			t.Errorf("input %q: wrong error position. expected=%q, got=%q", tt.input, tt.expectedPos, err.Pos.String())
+		}
+	}
+}
+
+func TestStackOverflow(t *testing.T) {
+	tests := []string{
+		"let f = fn(x) { x(x) }; f(f)",
+		"let count = fn(n) { count(n + 1) }; count(0)",
+	}
+
+	for _, input := range tests {
+		errObj, ok := testEval(input).(*object.Error)
+		if !ok || errObj.Message != "stack overflow" {
+			t.Errorf("%q: expected stack overflow, got %v", input, errObj)
+		}
+	}
+}
+
+func TestExecutionLimits(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		input    string
+		ctx      context.Context
+		opts     Options
+		expected string
+	}{
+		{"let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) } }; f(10)", context.Background(), Options{MaxDepth: 5}, "stack overflow"},
+		{"let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) } }; f(4)", context.Background(), Options{MaxDepth: 5}, ""},
+		{"let f = fn() { f() }; f()", context.Background(), Options{MaxSteps: 100}, "step limit exceeded: 100 steps"},
+		{"1 + 2 + 3", context.Background(), Options{MaxSteps: 7}, ""},
+		{"[1, 2, 3, 4]", context.Background(), Options{MaxObjects: 4}, "object limit exceeded: 4 objects"},
+		{`rest(rest([1, 2]))`, context.Background(), Options{MaxObjects: 4}, "object limit exceeded: 4 objects"},
+		{"1", ctx, Options{}, "execution cancelled: context canceled"},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		result := EvalContext(tt.ctx, program, object.NewEnvironment(), tt.opts)
+
+		errObj, isErr := result.(*object.Error)
+		if tt.expected == "" {
+			if isErr {
+				t.Errorf("%q: unexpected error %s", tt.input, errObj.Message)
+			}
+			continue
+		}
+		if !isErr || errObj.Message != tt.expected {
+			t.Errorf("%q: wrong result. want=%q, got=%v", tt.input, tt.expected, result)
+		}
+	}
+}
+
+func TestCancelRunningEvaluation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// 2^40 викликів - без скасування це не закінчиться
+	input := "let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) + f(n - 1) } }; f(40)"
+	program := parser.New(lexer.New(input)).ParseProgram()
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+	result := EvalContext(ctx, program, object.NewEnvironment(), Options{})
+
+	errObj, ok := result.(*object.Error)
+	if !ok || errObj.Message != "execution cancelled: context canceled" {
+		t.Errorf("expected cancellation error, got %v", result)
+	}
+}
+
+func TestBitwiseOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"0b1100 & 0b1010", 0b1000},
+		{"0b1100 | 0b1010", 0b1110},
+		{"0b1100 ^ 0b1010", 0b0110},
+		{"~0", -1},
+		{"~-8", 7},
+		{"1 << 10", 1024},
+		{"-16 >> 2", -4},
+		{"1 >> 64", 0},
+		{"-1 >> 100", -1},
+		{"0xFF & 0x0F << 4", 0xF0},
+		{"let flags = 0; flags = flags | 1 << 3; (flags & 8) != 0", true},
+		{"1 << -1", "negative shift count: -1"},
+		{"8 >> -2", "negative shift count: -2"},
+		{"1 << 63", "integer overflow: 1 << 63"},
+		{"3 << 62", "integer overflow: 3 << 62"},
+		{"0 << 100", 0},
+		{"1.5 & 1", "unknown operator: FLOAT & FLOAT"},
+		{"true | false", "unknown operator: BOOLEAN | BOOLEAN"},
+		{`"a" ^ "b"`, "unknown operator: STRING ^ STRING"},
+		{"~1.5", "unknown operator: ~FLOAT"},
+		{"~true", "unknown operator: ~BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok || errObj.Message != expected {
+				t.Errorf("%q: wrong result. want=%q, got=%v", tt.input, expected, evaluated)
+			}
+		}
+	}
+}
+
+func TestCheckedArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"7 / 2", 7 / 2},
+		{"-7 / 2", -7 / 2},
+		{"1 / 0", "division by zero"},
+		{"let zero = 0; 10 / zero", "division by zero"},
+		{"9223372036854775807", math.MaxInt64},
+		{"-9223372036854775807 - 1", math.MinInt64},
+		{"9223372036854775807 + 1", "integer overflow: 9223372036854775807 + 1"},
+		{"-9223372036854775807 - 2", "integer overflow: -9223372036854775807 - 2"},
+		{"4611686018427387904 * 2", "integer overflow: 4611686018427387904 * 2"},
+		{"-4611686018427387904 * 2", math.MinInt64},
+		{"let min = -9223372036854775807 - 1; min / -1", "integer overflow: -9223372036854775808 / -1"},
+		{"let min = -9223372036854775807 - 1; min * -1", "integer overflow: -9223372036854775808 * -1"},
+		{"let min = -9223372036854775807 - 1; -min", "integer overflow: -(-9223372036854775808)"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok || errObj.Message != expected {
+				t.Errorf("%q: wrong result. want=%q, got=%v", tt.input, expected, evaluated)
+			}
+		}
+	}
+}
+
+func TestBigArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"9223372036854775807 * 9223372036854775807", "85070591730234615847396907784232501249"},
+		{"let big = 9223372036854775807 + 1; big - 1", "9223372036854775807"},
+		{"let big = 9223372036854775807 * 4; big / 4 == 9223372036854775807", "true"},
+		{"let big = 9223372036854775807 + 1; big > 9223372036854775807", "true"},
+		{"let min = -9223372036854775807 - 1; -min", "9223372036854775808"},
+		{"let big = 9223372036854775807 + 1; -big", "-9223372036854775808"},
+		{"let big = 9223372036854775807 + 1; big / 0", "ERROR: 1:40: division by zero"},
+		{"let big = 9223372036854775807 + 1; [1, 2][big]", "null"},
+		{"let big = 9223372036854775807 + 1; {big: 1}[big]", "1"},
+		{"1 / 0", "ERROR: 1:3: division by zero"},
+		{"1 << 64", "18446744073709551616"},
+		{"let big = 1 << 64; big >> 63", "2"},
+		{"let big = 1 << 64; big >> 1000", "0"},
+		{"let big = 1 << 64; -big >> 9223372036854775807", "-1"},
+		{"let big = 1 << 64; (big | 1) & 3", "1"},
+		{"let big = 1 << 64; big ^ big", "0"},
+		{"let big = 1 << 64; ~big", "-18446744073709551617"},
+		{"let big = 1 << 64; 1 << big", "ERROR: 1:22: shift count too large: 18446744073709551616"},
+		{"let big = 1 << 64; big << -1", "ERROR: 1:24: negative shift count: -1"},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		result := EvalContext(context.Background(), program, object.NewEnvironment(), Options{Arithmetic: ArithmeticBig})
+		if result.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%s, got=%s", tt.input, tt.expected, result.Inspect())
+		}
+	}
+}
+
+func TestFloatExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"3.14", "3.14"},
+		{".5", "0.5"},
+		{"1e-9", "1e-09"},
+		{"2.0", "2.0"},
+		{"1e21", "1e+21"},
+		{"1000000.0", "1000000.0"},
+		{"1e20 + 0.5", "100000000000000000000.0"},
+		{"0.000001", "0.000001"},
+		{"-1.5", "-1.5"},
+		{"0.1 + 0.2", "0.30000000000000004"},
+		{"1 + 0.5", "1.5"},
+		{"0.5 * 4", "2.0"},
+		{"7 / 2.0", "3.5"},
+		{"7 / 2", "3"},
+		{"10 - 2.5 * 2", "5.0"},
+		{"1.5 < 2", "true"},
+		{"2 > 2.5", "false"},
+		{"1 == 1.0", "true"},
+		{"1.0 != 1", "false"},
+		{"1.0 / 0", "ERROR: 1:5: division by zero"},
+		{"1.5 + true", "ERROR: 1:5: type mismatch: FLOAT + BOOLEAN"},
+		{`1.5 + "a"`, "ERROR: 1:5: type mismatch: FLOAT + STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestNumericConversionBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"int(3.99)", "3"},
+		{"int(-3.99)", "-3"},
+		{"int(7)", "7"},
+		{`int(" 42 ")`, "42"},
+		{`int("4.2")`, `ERROR: 1:4: could not parse "4.2" as INTEGER`},
+		{"int(1e19)", "ERROR: 1:4: cannot convert 10000000000000000000.0 to INTEGER"},
+		{"int(true)", "ERROR: 1:4: argument to `int` not supported, got BOOLEAN"},
+		{"int(1, 2)", "ERROR: 1:4: wrong number of arguments. got=2, want=1"},
+		{"float(2)", "2.0"},
+		{"float(2.5)", "2.5"},
+		{`float("1e3")`, "1000.0"},
+		{`float("x")`, `ERROR: 1:6: could not parse "x" as FLOAT`},
+		{"float([])", "ERROR: 1:6: argument to `float` not supported, got ARRAY"},
+		{"let avg = fn(arr) { float(arr[0] + arr[1]) / len(arr) }; avg([1, 2])", "1.5"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestComparisonAndModuloOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 <= 2", "true"},
+		{"2 <= 2", "true"},
+		{"3 <= 2", "false"},
+		{"1 >= 2", "false"},
+		{"2 >= 2", "true"},
+		{"1.5 >= 1", "true"},
+		{"2 <= 1.5", "false"},
+		{`"a" <= "b"`, "true"},
+		{`"b" >= "c"`, "false"},
+		{"7 % 3", "1"},
+		{"-7 % 3", "-1"},
+		{"7 % -3", "1"},
+		{"1 + 10 % 4 * 2", "5"},
+		{"7.5 % 2", "1.5"},
+		{"7 % 0", "ERROR: 1:3: modulo by zero"},
+		{"7.5 % 0", "ERROR: 1:5: modulo by zero"},
+		{"true <= false", "ERROR: 1:6: unknown operator: BOOLEAN <= BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestLogicalOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"true && true", "true"},
+		{"true && false", "false"},
+		{"false || true", "true"},
+		{"false || false", "false"},
+		{"1 && \"a\"", "true"},
+		{"if (1 < 2 && 2 < 3) { 10 } else { 20 }", "10"},
+		{"1 > 2 || 2 > 3", "false"},
+		// правий операнд не обчислюється, тому помилки в ньому немає
+		{"false && missing", "false"},
+		{"true || missing", "true"},
+		{"false && (1 + true)", "false"},
+		{"true && missing", "ERROR: 1:9: identifier not found: missing"},
+		{"false || (1 + true)", "ERROR: 1:13: type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestConditionalAndCoalesce(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"true ? 1 : 2", "1"},
+		{"0 ? 1 : 2", "1"},
+		{"false ? 1 : true ? 2 : 3", "2"},
+		{`let x = 5; x > 3 ? "big" : "small"`, "big"},
+		{"let f = fn(n) { n < 2 ? n : f(n - 1) + f(n - 2) }; f(10)", "55"},
+		{"false ? missing : 3", "3"},
+		{"missing ? 1 : 2", "ERROR: 1:1: identifier not found: missing"},
+		{"[][0] ?? 7", "7"},
+		{"false ?? 7", "false"},
+		{"0 ?? 7", "0"},
+		{"1 ?? missing", "1"},
+		{`{}["k"] ?? [][0] ?? 3`, "3"},
+		{`let h = {"a": [1, 2]}; h["a"]?.[1]`, "2"},
+		{`let h = {}; h["a"]?.[missing]`, "null"},
+		{`let h = {}; h["a"]?.[0] ?? "none"`, "none"},
+		{"let f = [][0]; f?.(missing)", "null"},
+		{"let f = fn(x) { x * 2 }; f?.(4)", "8"},
+		{`let get = fn(h, k) { h?.[k] ?? 0 }; get({"a": 1}, "a") + get(first([]), "a")`, "1"},
+		// ?. діє тільки на одну операцію
+		{`let h = {}; h["a"]?.[0][1]`, "ERROR: 1:24: index operator not supported: NULL[INTEGER]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestWhileLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let i = 0; while (i < 5) { i = i + 1; } i", "5"},
+		{"let i = 0; while (false) { i = 1; } i", "0"},
+		{"let i = 0; let sum = 0; while (i < 10) { i = i + 1; if (i % 2 == 0) { continue; } sum = sum + i; } sum", "25"},
+		{"let i = 0; while (true) { if (i == 3) { break; } i = i + 1; } i", "3"},
+		{"let f = fn() { let i = 0; while (true) { i = i + 1; if (i > 4) { return i; } } }; f()", "5"},
+		{"let i = 0; while (i < 2) { i = i + 1; }; i", "2"},
+		{"while (missing) { }", "ERROR: 1:8: identifier not found: missing"},
+		{"let i = 0; while (i < 3) { i = i + true; } i", "ERROR: 1:34: type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestForLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let sum = 0; for (x in [1, 2, 3]) { sum = sum + x; } sum", "6"},
+		{`let keys = []; for (k in {"b": 1, "a": 2}) { keys = push(keys, k); } keys`, "[b, a]"},
+		{`let out = ""; for (ch in "añb") { out = ch + out; } out`, "bña"},
+		{"let sum = 0; for (i in range(5)) { sum = sum + i; } sum", "10"},
+		{"let out = []; for (i in range(10, 0, -3)) { out = push(out, i); } out", "[10, 7, 4, 1]"},
+		{"let n = 0; for (i in range(3, 3)) { n = n + 1; } n", "0"},
+		{"let x = 0; for (x in [7, 8]) { } x", "8"},
+		{"let pairs = []; for (i in range(1, 3)) { for (j in range(i)) { pairs = push(pairs, [i, j]); } } pairs", "[[1, 0], [2, 0], [2, 1]]"},
+		{"let out = []; for (i in range(10)) { if (i == 4) { break; } if (i % 2 == 1) { continue; } out = push(out, i); } out", "[0, 2]"},
+		{"let out = []; for (i in range(3)) { for (j in range(3)) { if (j == 1) { break; } out = push(out, [i, j]); } } out", "[[0, 0], [1, 0], [2, 0]]"},
+		{"let find = fn(arr, v) { for (x in arr) { if (x == v) { return true; } } false }; [find([1, 2], 2), find([1, 2], 3)]", "[true, false]"},
+		{"len(range(0, 10, 3))", "4"},
+		{"range(1, 4)", "range(1, 4)"},
+		{"for (x in 5) { }", "ERROR: 1:1: cannot iterate over INTEGER"},
+		{"range(0, 1, 0)", "ERROR: 1:6: range step must not be zero"},
+		{`range("a")`, "ERROR: 1:6: argument to `range` must be INTEGER, got STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestControlFlowInExpressions(t *testing.T) {
+	// break, continue і return посеред виразу обривають його, як і помилка
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let i = 0; let s = 0; while (i < 5) { i += 1; let x = if (i > 1) { break; } else { 1 }; s += x; } s", "1"},
+		{"let n = 0; for (x in [1, 2]) { n += 1; puts(if (true) { continue; }); } n", "2"},
+		{"let r = 0; for (x in [1, 2, 3]) { r += 1 + if (x == 2) { continue; } else { 0 } } r", "2"},
+		{"let i = 0; while (i < 3000) { i += 1; [1, 2, if (true) { continue; }] } i", "3000"},
+		{"let s = 0; for (x in [1, 2, 3]) { for (y in [1, 2]) { s += [y, if (y == 2) { break; }][0] } } s", "3"},
+		{`let s = 0; for (x in [1, 2]) { s += {"a": if (x == 1) { continue; } else { x }}["a"] } s`, "2"},
+		{"let f = fn() { let x = if (true) { return 9; }; 5 }; f()", "9"},
+		{"let f = fn() { for (x in [1]) { [1, if (true) { return 7; }] } 0 }; f() + 1", "8"},
+		{"let x = if (true) { return 9; }; 5", "9"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestLoopLimits(t *testing.T) {
+	program := parser.New(lexer.New("while (true) { }")).ParseProgram()
+	result := EvalContext(context.Background(), program, object.NewEnvironment(), Options{MaxSteps: 1000})
+	if !isError(result) || result.(*object.Error).Message != "step limit exceeded: 1000 steps" {
+		t.Errorf("wrong result for infinite loop. got=%v", result)
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 1; x = 5; x", "5"},
+		{"let x = 1; x = x + 1", "2"},
+		{"let x = 10; x += 5; x -= 3; x *= 2; x /= 4; x", "6"},
+		{`let s = "a"; s += "b"; s`, "ab"},
+		{"let a = 1; let b = 2; a = b = 7; [a, b]", "[7, 7]"},
+		{"let x = 1; let f = fn() { x = 2; }; f(); x", "2"},
+		{"let f = fn() { g = 3; }; let g = 0; f(); g", "3"},
+		{"let i = 0; while (i < 3) { i += 1; } i", "3"},
+		{"let arr = [1, 2, 3]; arr[0] = 10; arr[-1] += 5; arr", "[10, 2, 8]"},
+		{"let a = [1]; let b = a; b[0] = 2; a", "[2]"},
+		{`let h = {"a": 1}; h["b"] = 2; h["a"] *= 10; h`, "{a: 10, b: 2}"},
+		{"let m = [[0, 0], [0, 0]]; m[1][0] = 4; m", "[[0, 0], [4, 0]]"},
+		{"x = 1", "ERROR: 1:3: assignment to undeclared identifier: x"},
+		{"len = 1", "ERROR: 1:5: assignment to undeclared identifier: len"},
+		{"missing += 1", "ERROR: 1:1: identifier not found: missing"},
+		{"let x = 1; x += true", "ERROR: 1:14: type mismatch: INTEGER + BOOLEAN"},
+		{"let arr = [1]; arr[1] = 2", "ERROR: 1:23: index out of range: 1 (length 1)"},
+		{`let arr = [1]; arr["a"] = 2`, "ERROR: 1:25: index operator not supported: ARRAY[STRING]"},
+		{`let h = {}; h[[1]] = 2`, "ERROR: 1:20: unusable as hash key: ARRAY"},
+		{`let s = "ab"; s[0] = "c"`, "ERROR: 1:20: index assignment not supported: STRING[INTEGER]"},
+		{`let h = {}; h["a"] += 1`, "ERROR: 1:20: type mismatch: NULL + INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestAssignCapturedVariable(t *testing.T) {
+	// замикання і функція, що його створила, змінюють одну змінну
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let make = fn() { let c = 0; fn() { c = c + 1; c } }; let f = make(); f(); f()", 2},
+		{"let counter = fn() { let n = 0; fn() { n += 1; n } }; let next = counter(); next(); next(); next()", 3},
+		{"let f = fn() { let x = 1; let get = fn() { x }; x = 5; get() }; f()", 5},
+		{"let f = fn() { let n = 0; let inc = fn() { fn() { n += 10 } }; inc()(); inc()(); n }; f()", 20},
+		// кожен виклик має свою змінну
+		{"let make = fn() { let c = 0; fn() { c += 1 } }; let a = make(); a(); a(); let b = make(); b() * 10 + a()", 13},
+		// ім'я функції всередині неї - звичайна змінна з let
+		{"let f = fn() { f = 1 }; f(); f", 1},
+		{"let g = fn() { let f = fn() { f = 2; 0 }; f(); f }; g()", 2},
+		{"let g = fn() { let count = fn(n) { if (n == 0) { 0 } else { 1 + count(n - 1) } }; count(5) }; g()", 5},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestConstBindings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"const x = 5; x * 2", "10"},
+		{"const f = fn(n) { n + 1 }; f(1)", "2"},
+		{"const arr = [1]; arr[0] = 2; arr", "[2]"},
+		{"let inc = fn() { n += 1; }; let n = 0; inc(); n", "1"},
+		// на момент розбору inc ім'я n ще не оголошене, тому помилка лише під час виконання
+		{"let inc = fn() { n += 1; }; const n = 0; inc()", "ERROR: 1:20: cannot assign to constant: n"},
+		// сусідні блоки можуть оголошувати одне й те саме ім'я
+		{"if (true) { let x = 1; x } else { let x = 2; x }", "1"},
+		{"let f = fn(c) { if (c) { let x = 1; x } else { let x = 2; x } }; f(true) + f(false)", "3"},
+		{"if (true) { const k = 1; } if (true) { let k = 2; k = 3; k }", "3"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. want=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	env := object.NewEnvironment()
+	Eval(parser.New(lexer.New("const a = 1; let b = 2;")).ParseProgram(), env)
+	if binding, ok := env.Lookup("a"); !ok || !binding.Constant {
+		t.Errorf("a is not a constant binding. got=%+v", binding)
+	}
+	if binding, ok := env.Lookup("b"); !ok || binding.Constant {
+		t.Errorf("b is a constant binding. got=%+v", binding)
+	}
+}
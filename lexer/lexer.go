@@ -1,36 +1,174 @@
 package lexer
 
 import (
+	"bufio"
 	"interpreter/token"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
+// Lexer читає UTF-8 текст по символах (рунах). Offset у позиціях рахується в байтах,
+// Column - в символах. ввід читається потоком: у пам'яті тримається лише кілька символів
+// наперед і текст поточного токена
 type Lexer struct {
-	input        string
-	position     int
-	readPosition int
-	ch           byte
+	reader *bufio.Reader
+	// err - перша помилка читання, крім io.EOF. після неї лексер бачить кінець вводу
+	err error
+
+	position     int // байтовий зсув l.ch
+	readPosition int // байтовий зсув наступного символу
+	ch           rune
+	// width - скільки байтів займає l.ch у вводі, raw - самі ці байти
+	// (для невалідного UTF-8 це вихідний байт, а не U+FFFD)
+	width int
+	raw   [utf8.UTFMax]byte
+
+	// text - символи, прочитані після startText, див. stopText
+	text      []byte
+	recording bool
+
+	filename string
+	// рядок і колонка символу l.ch
+	line   int
+	column int
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	return NewWithFilename("", input)
+}
+
+// NewWithFilename - як New, але позиції токенів міститимуть ім'я файлу
+func NewWithFilename(filename, input string) *Lexer {
+	return NewReaderWithFilename(filename, strings.NewReader(input))
+}
+
+// NewReader створює лексер, що читає r поступово, тож великий скрипт не треба
+// завантажувати в пам'ять цілком. токени ті самі, що й від New для того ж тексту
+func NewReader(r io.Reader) *Lexer {
+	return NewReaderWithFilename("", r)
+}
+
+func NewReaderWithFilename(filename string, r io.Reader) *Lexer {
+	l := &Lexer{reader: bufio.NewReader(r), filename: filename, line: 1}
 	l.readChar()
+	l.skipShebang()
 	return l
 }
 
+// Err повертає помилку читання вводу, якщо вона була. на ній лексер видає EOF
+func (l *Lexer) Err() error {
+	return l.err
+}
+
+// skipShebang пропускає перший рядок виду "#!/usr/bin/env monkey",
+// щоб скрипти можна було запускати напряму
+func (l *Lexer) skipShebang() {
+	if l.ch != '#' || l.peekChar() != '!' {
+		return
+	}
+	for l.ch != '\n' && l.ch != 0 {
+		l.readChar()
+	}
+}
+
 func (l *Lexer) readChar() {
-	if l.readPosition >= len(l.input) {
-		l.ch = 0
-	} else {
-		l.ch = l.input[l.readPosition]
+	if l.recording {
+		l.text = append(l.text, l.raw[:l.width]...)
 	}
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	ch, raw := l.peek(0)
+	l.ch, l.width = ch, copy(l.raw[:], raw)
+	l.reader.Discard(l.width)
 	l.position = l.readPosition
-	l.readPosition += 1
+	l.readPosition += l.width
+	l.column++
+}
+
+// peek декодує символ, що починається через skip байтів після l.ch, не рухаючи читач,
+// і повертає його разом з його байтами. лексер дивиться вперед щонайбільше на два символи,
+// тож це завжди вміщається в буфер bufio.Reader
+func (l *Lexer) peek(skip int) (rune, []byte) {
+	buf := l.fill(skip + 1)
+	if len(buf) <= skip {
+		return 0, nil
+	}
+	buf = l.fill(skip + runeLen(buf[skip]))
+	ch, width := utf8.DecodeRune(buf[skip:])
+	return ch, buf[skip : skip+width]
+}
+
+// fill повертає до n наступних байтів вводу; менше - тільки на кінці вводу
+func (l *Lexer) fill(n int) []byte {
+	buf, err := l.reader.Peek(n)
+	if err != nil && err != io.EOF && l.err == nil {
+		l.err = err
+	}
+	return buf
+}
+
+// runeLen - довжина символу в UTF-8 за його першим байтом. для байтів, з яких
+// символ не може починатися, повертає 1: DecodeRune і так видасть RuneError
+func runeLen(b byte) int {
+	switch {
+	case b < 0xC0:
+		return 1
+	case b < 0xE0:
+		return 2
+	case b < 0xF0:
+		return 3
+	default:
+		return utf8.UTFMax
+	}
+}
+
+// startText починає запам'ятовувати символи від l.ch, stopText повертає все прочитане
+// з того моменту до l.ch (не включно)
+func (l *Lexer) startText() {
+	l.text = l.text[:0]
+	l.recording = true
+}
+
+func (l *Lexer) stopText() string {
+	l.recording = false
+	return string(l.text)
+}
+
+// invalidChar - чи є l.ch байтом, що не утворює валідного UTF-8
+func (l *Lexer) invalidChar() bool {
+	return l.ch == utf8.RuneError && l.width == 1
+}
+
+// currentPosition - позиція символу l.ch
+func (l *Lexer) currentPosition() token.Position {
+	return token.Position{
+		Filename: l.filename,
+		Offset:   l.position,
+		Line:     l.line,
+		Column:   l.column,
+	}
 }
 
 func (l *Lexer) NextToken() token.Token {
+	doc, unclosed, ok := l.skipTrivia()
+	if !ok {
+		return unclosed
+	}
+
+	tok := l.readToken()
+	tok.Doc = doc
+	return tok
+}
+
+func (l *Lexer) readToken() token.Token {
 	var tok token.Token
 
-	l.skipWhitespaces()
+	pos := l.currentPosition()
 
 	switch l.ch {
 	case '!':
@@ -41,15 +179,67 @@ func (l *Lexer) NextToken() token.Token {
 		}
 
 	case '*':
-		tok = newToken(token.ASTERISK, l.ch)
+		if l.peekChar() == '=' {
+			tok = l.makeTwoCharToken(token.ASTERISK_ASSIGN)
+		} else {
+			tok = newToken(token.ASTERISK, l.ch)
+		}
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		if l.peekChar() == '=' {
+			tok = l.makeTwoCharToken(token.MINUS_ASSIGN)
+		} else {
+			tok = newToken(token.MINUS, l.ch)
+		}
 	case '/':
-		tok = newToken(token.SLASH, l.ch)
+		if l.peekChar() == '=' {
+			tok = l.makeTwoCharToken(token.SLASH_ASSIGN)
+		} else {
+			tok = newToken(token.SLASH, l.ch)
+		}
+	case '%':
+		tok = newToken(token.PERCENT, l.ch)
+	case '^':
+		tok = newToken(token.CARET, l.ch)
+	case '~':
+		tok = newToken(token.TILDE, l.ch)
+	case '?':
+		switch {
+		case l.peekChar() == '?':
+			tok = l.makeTwoCharToken(token.COALESCE)
+		// c ?.5 : 1 - це тернарний оператор і дробове число, а не ?.
+		case l.peekChar() == '.' && !isDigit(l.peekSecondChar()):
+			tok = l.makeTwoCharToken(token.QUESTION_DOT)
+		default:
+			tok = newToken(token.QUESTION, l.ch)
+		}
 	case '<':
-		tok = newToken(token.LT, l.ch)
+		if l.peekChar() == '=' {
+			tok = l.makeTwoCharToken(token.LT_EQ)
+		} else if l.peekChar() == '<' {
+			tok = l.makeTwoCharToken(token.SHIFT_LEFT)
+		} else {
+			tok = newToken(token.LT, l.ch)
+		}
 	case '>':
-		tok = newToken(token.GT, l.ch)
+		if l.peekChar() == '=' {
+			tok = l.makeTwoCharToken(token.GT_EQ)
+		} else if l.peekChar() == '>' {
+			tok = l.makeTwoCharToken(token.SHIFT_RIGHT)
+		} else {
+			tok = newToken(token.GT, l.ch)
+		}
+	case '&':
+		if l.peekChar() == '&' {
+			tok = l.makeTwoCharToken(token.AND)
+		} else {
+			tok = newToken(token.AMPERSAND, l.ch)
+		}
+	case '|':
+		if l.peekChar() == '|' {
+			tok = l.makeTwoCharToken(token.OR)
+		} else {
+			tok = newToken(token.PIPE, l.ch)
+		}
 	case '=':
 		if l.peekChar() == '=' {
 			tok = l.makeTwoCharToken(token.EQ)
@@ -58,6 +248,8 @@ func (l *Lexer) NextToken() token.Token {
 		}
 	case ';':
 		tok = newToken(token.SEMICOLON, l.ch)
+	case ':':
+		tok = newToken(token.COLON, l.ch)
 	case '(':
 		tok = newToken(token.LPAREN, l.ch)
 	case ')':
@@ -65,29 +257,56 @@ func (l *Lexer) NextToken() token.Token {
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
 	case '+':
-		tok = newToken(token.PLUS, l.ch)
+		if l.peekChar() == '=' {
+			tok = l.makeTwoCharToken(token.PLUS_ASSIGN)
+		} else {
+			tok = newToken(token.PLUS, l.ch)
+		}
 	case '{':
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
 		tok = newToken(token.RBRACE, l.ch)
+	case '[':
+		tok = newToken(token.LBRACKET, l.ch)
+	case ']':
+		tok = newToken(token.RBRACKET, l.ch)
+	case '"':
+		literal, ok := l.readString()
+		if ok {
+			tok.Type = token.STRING
+		} else {
+			tok.Type = token.ILLEGAL
+		}
+		tok.Literal = literal
 	case 0:
+		// на кінці вводу не рухаємось далі, щоб усі EOF мали однакову позицію
 		tok.Type = token.EOF
 		tok.Literal = ""
+		tok.Pos = pos
+		tok.End = l.currentPosition()
+		return tok
 	default:
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
+			tok.Pos = pos
+			tok.End = l.currentPosition()
 			return tok
-		} else if isDigit(l.ch) {
-			tok.Literal = l.readNumber()
-			tok.Type = token.INT
+		} else if isDigit(l.ch) || (l.ch == '.' && isDigit(l.peekChar())) {
+			tok.Literal, tok.Type = l.readNumber()
+			tok.Pos = pos
+			tok.End = l.currentPosition()
 			return tok
+		} else if l.invalidChar() {
+			tok = token.Token{Type: token.ILLEGAL, Literal: string(l.raw[:l.width])}
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
 		}
 
 	}
 	l.readChar()
+	tok.Pos = pos
+	tok.End = l.currentPosition()
 	return tok
 }
 
@@ -101,24 +320,149 @@ func (l *Lexer) makeTwoCharToken(tokType token.TokenType) token.Token {
 	}
 }
 
-func (l *Lexer) readNumber() string {
-	pos := l.position
+// readNumber читає ціле або дробове число: 42, 1_000, 0xFF, 0o755, 0b1010, 3.14, .5, 1e-9.
+// крапка і експонента належать числу, тільки якщо за ними йде цифра. правильність
+// цифр і '_' перевіряє парсер, щоб вказати на конкретний символ
+func (l *Lexer) readNumber() (string, token.TokenType) {
+	l.startText()
+	if l.ch == '0' && isBasePrefix(l.peekChar()) {
+		// після префікса читаємо всі літери й цифри, щоб 0b102 чи 0xFG були одним токеном
+		l.readChar()
+		l.readChar()
+		for isLetter(l.ch) || isIdentifierDigit(l.ch) {
+			l.readChar()
+		}
+		return l.stopText(), token.INT
+	}
+
+	tokType := token.TokenType(token.INT)
+
+	l.readDigits()
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		tokType = token.FLOAT
+		l.readChar()
+		l.readDigits()
+	}
+	if l.ch == 'e' || l.ch == 'E' {
+		next := l.peekChar()
+		digit := next
+		sign := next == '+' || next == '-'
+		if sign {
+			digit = l.peekSecondChar()
+		}
+		if isDigit(digit) {
+			tokType = token.FLOAT
+			l.readChar()
+			if sign {
+				l.readChar()
+			}
+			l.readDigits()
+		}
+	}
+	return l.stopText(), tokType
+}
+
+// readDigits читає десяткові цифри разом з роздільниками '_'
+func (l *Lexer) readDigits() {
+	for isDigit(l.ch) || l.ch == '_' {
+		l.readChar()
+	}
+}
 
-	for isDigit(l.ch) {
+func isBasePrefix(ch rune) bool {
+	switch ch {
+	case 'x', 'X', 'o', 'O', 'b', 'B':
+		return true
+	}
+	return false
+}
+
+// readString читає рядок між лапками, розкриваючи escape-послідовності.
+// Якщо рядок не закритий або містить невідомий escape - повертає false,
+// але все одно дочитує до закриваючої лапки
+func (l *Lexer) readString() (string, bool) {
+	var out strings.Builder
+	valid := true
+
+	for {
 		l.readChar()
+		switch l.ch {
+		case '"':
+			return out.String(), valid
+		case 0:
+			return out.String(), false
+		case '\\':
+			l.readChar()
+			switch l.ch {
+			case 'n':
+				out.WriteByte('\n')
+			case 't':
+				out.WriteByte('\t')
+			case 'r':
+				out.WriteByte('\r')
+			case '"':
+				out.WriteByte('"')
+			case '\\':
+				out.WriteByte('\\')
+			case 'u':
+				r, ok := l.readUnicodeEscape()
+				if !ok {
+					valid = false
+					continue
+				}
+				out.WriteRune(r)
+			case 0:
+				return out.String(), false
+			default:
+				valid = false
+			}
+		default:
+			if l.invalidChar() {
+				valid = false
+			}
+			out.WriteRune(l.ch)
+		}
 	}
-	return l.input[pos:l.position]
 }
 
-func (l *Lexer) peekChar() byte {
-	if l.readPosition >= len(l.input) {
-		return 0
-	} else {
-		return l.input[l.readPosition]
+// readUnicodeEscape читає \u{XXXX}, поточний символ - 'u'
+func (l *Lexer) readUnicodeEscape() (rune, bool) {
+	if l.peekChar() != '{' {
+		return 0, false
+	}
+	l.readChar()
+
+	var hex strings.Builder
+	for l.peekChar() != '}' {
+		if l.peekChar() == 0 || l.peekChar() == '"' {
+			return 0, false
+		}
+		l.readChar()
+		hex.WriteRune(l.ch)
+	}
+	l.readChar()
+
+	code, err := strconv.ParseUint(hex.String(), 16, 32)
+	if err != nil || !utf8.ValidRune(rune(code)) {
+		return 0, false
 	}
+	return rune(code), true
+}
+
+func (l *Lexer) peekChar() rune {
+	ch, _ := l.peek(0)
+	return ch
+}
+
+// peekSecondChar - символ, що йде за peekChar
+func (l *Lexer) peekSecondChar() rune {
+	_, raw := l.peek(0)
+	ch, _ := l.peek(len(raw))
+	return ch
 }
 
-func isDigit(ch byte) bool {
+// isDigit - тільки ASCII цифри: з них складаються числа
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
@@ -130,21 +474,96 @@ func (l *Lexer) skipWhitespaces() {
 	}
 }
 
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+// skipTrivia пропускає пробіли і коментарі: // до кінця рядка і /* */, які можуть бути вкладені.
+// повертає текст /// коментарів, між якими і токеном немає порожнього рядка чи іншого коментаря.
+// якщо блоковий коментар не закрито, повертає ok=false і ILLEGAL токен від його початку до кінця вводу
+func (l *Lexer) skipTrivia() (doc string, unclosed token.Token, ok bool) {
+	var lines []string
+	for {
+		line := l.line
+		l.skipWhitespaces()
+		if l.line-line > 1 {
+			lines = nil
+		}
+
+		if l.ch != '/' || (l.peekChar() != '/' && l.peekChar() != '*') {
+			return strings.Join(lines, "\n"), unclosed, true
+		}
+
+		if l.peekChar() == '*' {
+			lines = nil
+			start := l.currentPosition()
+			l.startText()
+			closed := l.skipBlockComment()
+			text := l.stopText()
+			if !closed {
+				return "", token.Token{Type: token.ILLEGAL, Literal: text, Pos: start, End: l.currentPosition()}, false
+			}
+			continue
+		}
+
+		text := l.readLineComment()
+		if strings.HasPrefix(text, "///") && !strings.HasPrefix(text, "////") {
+			lines = append(lines, strings.TrimPrefix(strings.TrimPrefix(text, "///"), " "))
+		} else {
+			lines = nil
+		}
+	}
+}
+
+// readLineComment читає коментар від "//" до кінця рядка, без '\n'
+func (l *Lexer) readLineComment() string {
+	l.startText()
+	for l.ch != '\n' && l.ch != 0 {
+		l.readChar()
+	}
+	return strings.TrimRight(l.stopText(), "\r")
+}
+
+// skipBlockComment пропускає /* ... */ разом з вкладеними коментарями.
+// false - кінець вводу раніше, ніж коментар закрився
+func (l *Lexer) skipBlockComment() bool {
+	depth := 0
+	for l.ch != 0 {
+		switch {
+		case l.ch == '/' && l.peekChar() == '*':
+			depth++
+			l.readChar()
+		case l.ch == '*' && l.peekChar() == '/':
+			depth--
+			l.readChar()
+			if depth == 0 {
+				l.readChar()
+				return true
+			}
+		}
+		l.readChar()
+	}
+	return false
+}
+
+// isLetter і isIdentifierDigit - правила ідентифікаторів Go: ідентифікатор починається
+// з літери Unicode або '_', далі можуть йти літери і цифри, наприклад ціна2
+func isLetter(ch rune) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' ||
+		ch >= utf8.RuneSelf && unicode.IsLetter(ch)
+}
+
+func isIdentifierDigit(ch rune) bool {
+	return isDigit(ch) || ch >= utf8.RuneSelf && unicode.IsDigit(ch)
 }
 
 func (l *Lexer) readIdentifier() string {
-	pos := l.position
+	l.startText()
 
-	for isLetter(l.ch) {
+	for isLetter(l.ch) || isIdentifierDigit(l.ch) {
 		l.readChar()
 	}
 
-	return l.input[pos:l.position]
+	return l.stopText()
 }
 
-func newToken(tokenType token.TokenType, ch byte) token.Token {
+func newToken(tokenType token.TokenType, ch rune) token.Token {
 	return token.Token{
 		Type:    tokenType,
 		Literal: string(ch),
@@ -1,10 +1,36 @@
 package lexer
 
 import (
+	"errors"
 	"interpreter/token"
+	"io"
+	"os"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
+// тести створюють лексер через newLexer і newLexerWithFilename. TestMain проганяє їх
+// двічі: з лексером над рядком і з потоковим, читач якого віддає по одному байту
+var (
+	newLexer             = New
+	newLexerWithFilename = NewWithFilename
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if code == 0 {
+		newLexerWithFilename = func(filename, input string) *Lexer {
+			return NewReaderWithFilename(filename, iotest.OneByteReader(strings.NewReader(input)))
+		}
+		newLexer = func(input string) *Lexer {
+			return newLexerWithFilename("", input)
+		}
+		code = m.Run()
+	}
+	os.Exit(code)
+}
+
 func TestNextToken(t *testing.T) {
 	input := `
 			let five = 5;
@@ -13,7 +39,7 @@ func TestNextToken(t *testing.T) {
 				x + y;
 			};
 			let result = add(five, ten);
-			!-/*5;
+			!-/ *5;
 			5 < 10 > 5;
 			if (5 < 10) {
 				return true;
@@ -22,6 +48,12 @@ func TestNextToken(t *testing.T) {
 			}
 			10 == 10;
 			10 != 9;
+			"foobar"
+			"foo bar"
+			"a\n\t\"b\"\\"
+			"\u{1F600}"
+			[1, 2];
+			{"foo": "bar"}
 `
 
 	tests := []struct {
@@ -101,10 +133,25 @@ func TestNextToken(t *testing.T) {
 		{token.NOT_EQ, "!="},
 		{token.INT, "9"},
 		{token.SEMICOLON, ";"},
+		{token.STRING, "foobar"},
+		{token.STRING, "foo bar"},
+		{token.STRING, "a\n\t\"b\"\\"},
+		{token.STRING, "\U0001F600"},
+		{token.LBRACKET, "["},
+		{token.INT, "1"},
+		{token.COMMA, ","},
+		{token.INT, "2"},
+		{token.RBRACKET, "]"},
+		{token.SEMICOLON, ";"},
+		{token.LBRACE, "{"},
+		{token.STRING, "foo"},
+		{token.COLON, ":"},
+		{token.STRING, "bar"},
+		{token.RBRACE, "}"},
 		{token.EOF, ""},
 	}
 
-	l := New(input)
+	l := newLexer(input)
 
 	for i, tt := range tests {
 		tok := l.NextToken()
@@ -117,3 +164,337 @@ func TestNextToken(t *testing.T) {
 	}
 
 }
+
+func TestIllegalStrings(t *testing.T) {
+	tests := []struct {
+		input string
+	}{
+		{`"unterminated`},
+		{`"bad \q escape"`},
+		{`"\u{110000}"`},
+		{`"\u{zz}"`},
+		{`"\u1234"`},
+	}
+
+	for _, tt := range tests {
+		l := newLexer(tt.input)
+		tok := l.NextToken()
+		if tok.Type != token.ILLEGAL {
+			t.Errorf("input %q - tokentype wrong. expected=%q, got=%q", tt.input, token.ILLEGAL, tok.Type)
+		}
+		if next := l.NextToken(); next.Type != token.EOF {
+			t.Errorf("input %q - expected EOF after string, got=%q", tt.input, next.Type)
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []token.Token
+	}{
+		{"42", []token.Token{{Type: token.INT, Literal: "42"}}},
+		{"3.14", []token.Token{{Type: token.FLOAT, Literal: "3.14"}}},
+		{".5", []token.Token{{Type: token.FLOAT, Literal: ".5"}}},
+		{"1e-9", []token.Token{{Type: token.FLOAT, Literal: "1e-9"}}},
+		{"2.5E+3", []token.Token{{Type: token.FLOAT, Literal: "2.5E+3"}}},
+		{"1e5", []token.Token{{Type: token.FLOAT, Literal: "1e5"}}},
+		{"0xFF", []token.Token{{Type: token.INT, Literal: "0xFF"}}},
+		{"0o755 0B1010", []token.Token{{Type: token.INT, Literal: "0o755"}, {Type: token.INT, Literal: "0B1010"}}},
+		{"1_000_000", []token.Token{{Type: token.INT, Literal: "1_000_000"}}},
+		{"1_000.5e1_0", []token.Token{{Type: token.FLOAT, Literal: "1_000.5e1_0"}}},
+		// неправильні цифри лишаються в токені, їх перевіряє парсер
+		{"0b102", []token.Token{{Type: token.INT, Literal: "0b102"}}},
+		{"0xfg;", []token.Token{{Type: token.INT, Literal: "0xfg"}, {Type: token.SEMICOLON, Literal: ";"}}},
+		{"0x", []token.Token{{Type: token.INT, Literal: "0x"}}},
+		{"1.", []token.Token{{Type: token.INT, Literal: "1"}, {Type: token.ILLEGAL, Literal: "."}}},
+		{"1e", []token.Token{{Type: token.INT, Literal: "1"}, {Type: token.IDENT, Literal: "e"}}},
+		{"1e+", []token.Token{{Type: token.INT, Literal: "1"}, {Type: token.IDENT, Literal: "e"}, {Type: token.PLUS, Literal: "+"}}},
+		{"a[0].5", []token.Token{
+			{Type: token.IDENT, Literal: "a"},
+			{Type: token.LBRACKET, Literal: "["},
+			{Type: token.INT, Literal: "0"},
+			{Type: token.RBRACKET, Literal: "]"},
+			{Type: token.FLOAT, Literal: ".5"},
+		}},
+	}
+
+	for _, tt := range tests {
+		l := newLexer(tt.input)
+		for i, expected := range tt.expected {
+			tok := l.NextToken()
+			if tok.Type != expected.Type || tok.Literal != expected.Literal {
+				t.Errorf("input %q, token %d - wrong token. expected=%s %q, got=%s %q",
+					tt.input, i, expected.Type, expected.Literal, tok.Type, tok.Literal)
+			}
+		}
+		if tok := l.NextToken(); tok.Type != token.EOF {
+			t.Errorf("input %q - expected EOF, got=%s %q", tt.input, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestTwoCharOperators(t *testing.T) {
+	input := "a <= b >= c % d && e || f & | ^ ~g << 1 >> 2 ? : ?? ?.[ ?.5"
+
+	expected := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.LT_EQ, "<="},
+		{token.IDENT, "b"},
+		{token.GT_EQ, ">="},
+		{token.IDENT, "c"},
+		{token.PERCENT, "%"},
+		{token.IDENT, "d"},
+		{token.AND, "&&"},
+		{token.IDENT, "e"},
+		{token.OR, "||"},
+		{token.IDENT, "f"},
+		{token.AMPERSAND, "&"},
+		{token.PIPE, "|"},
+		{token.CARET, "^"},
+		{token.TILDE, "~"},
+		{token.IDENT, "g"},
+		{token.SHIFT_LEFT, "<<"},
+		{token.INT, "1"},
+		{token.SHIFT_RIGHT, ">>"},
+		{token.INT, "2"},
+		{token.QUESTION, "?"},
+		{token.COLON, ":"},
+		{token.COALESCE, "??"},
+		{token.QUESTION_DOT, "?."},
+		{token.LBRACKET, "["},
+		{token.QUESTION, "?"},
+		{token.FLOAT, ".5"},
+		{token.EOF, ""},
+	}
+
+	l := newLexer(input)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - wrong token. expected=%s %q, got=%s %q",
+				i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestAssignOperators(t *testing.T) {
+	input := "a = b += c -= d *= e /= f"
+
+	expected := []token.TokenType{
+		token.IDENT, token.ASSIGN, token.IDENT, token.PLUS_ASSIGN, token.IDENT, token.MINUS_ASSIGN,
+		token.IDENT, token.ASTERISK_ASSIGN, token.IDENT, token.SLASH_ASSIGN, token.IDENT, token.EOF,
+	}
+
+	l := newLexer(input)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt {
+			t.Fatalf("tests[%d] - wrong token type. expected=%s, got=%s (%q)", i, tt, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestLoopKeywords(t *testing.T) {
+	input := "while for in break continue inside"
+
+	expected := []token.TokenType{
+		token.WHILE, token.FOR, token.IN, token.BREAK, token.CONTINUE, token.IDENT, token.EOF,
+	}
+
+	l := newLexer(input)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt {
+			t.Fatalf("tests[%d] - wrong token type. expected=%s, got=%s (%q)", i, tt, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `a // line comment
+/* block /* nested */ still comment */ b / c /= d
+/// first line
+///second line
+let
+/// not attached: blank line follows
+
+x // comment resets
+/// doc
+/* also resets */ y
+//// four slashes are a plain comment
+z /* unterminated /* */`
+
+	expected := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedDoc     string
+		expectedLine    int
+	}{
+		{token.IDENT, "a", "", 1},
+		{token.IDENT, "b", "", 2},
+		{token.SLASH, "/", "", 2},
+		{token.IDENT, "c", "", 2},
+		{token.SLASH_ASSIGN, "/=", "", 2},
+		{token.IDENT, "d", "", 2},
+		{token.LET, "let", "first line\nsecond line", 5},
+		{token.IDENT, "x", "", 8},
+		{token.IDENT, "y", "", 10},
+		{token.IDENT, "z", "", 12},
+		{token.ILLEGAL, "/* unterminated /* */", "", 12},
+		{token.EOF, "", "", 12},
+	}
+
+	l := newLexer(input)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - wrong token. expected=%s %q, got=%s %q",
+				i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+		if tok.Doc != tt.expectedDoc {
+			t.Errorf("tests[%d] - wrong doc. expected=%q, got=%q", i, tt.expectedDoc, tok.Doc)
+		}
+		if tok.Pos.Line != tt.expectedLine {
+			t.Errorf("tests[%d] - wrong line. expected=%d, got=%d", i, tt.expectedLine, tok.Pos.Line)
+		}
+	}
+}
+
+func TestUnicode(t *testing.T) {
+	input := "let ціна = x1 + _a_2;\n\"привіт\" 日本語 ціна٣ x\xffy"
+
+	expected := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+		expectedOffset  int
+	}{
+		{token.LET, "let", 1, 1, 0},
+		{token.IDENT, "ціна", 1, 5, 4},
+		{token.ASSIGN, "=", 1, 10, 13},
+		{token.IDENT, "x1", 1, 12, 15},
+		{token.PLUS, "+", 1, 15, 18},
+		{token.IDENT, "_a_2", 1, 17, 20},
+		{token.SEMICOLON, ";", 1, 21, 24},
+		{token.STRING, "привіт", 2, 1, 26},
+		{token.IDENT, "日本語", 2, 10, 41},
+		{token.IDENT, "ціна٣", 2, 14, 51},
+		{token.IDENT, "x", 2, 20, 62},
+		{token.ILLEGAL, "\xff", 2, 21, 63},
+		{token.IDENT, "y", 2, 22, 64},
+		{token.EOF, "", 2, 23, 65},
+	}
+
+	l := newLexer(input)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - wrong token. expected=%s %q, got=%s %q",
+				i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+		if tok.Pos.Line != tt.expectedLine || tok.Pos.Column != tt.expectedColumn || tok.Pos.Offset != tt.expectedOffset {
+			t.Errorf("tests[%d] - wrong position. expected=%d:%d (offset %d), got=%d:%d (offset %d)", i,
+				tt.expectedLine, tt.expectedColumn, tt.expectedOffset, tok.Pos.Line, tok.Pos.Column, tok.Pos.Offset)
+		}
+	}
+
+	if tok := newLexer("\"a\xffb\"").NextToken(); tok.Type != token.ILLEGAL {
+		t.Errorf("string with invalid UTF-8 is not ILLEGAL. got=%s %q", tok.Type, tok.Literal)
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	input := "let x = 5;\n  add(x, \"a b\")\n"
+
+	tests := []struct {
+		expectedType   token.TokenType
+		expectedOffset int
+		expectedLine   int
+		expectedColumn int
+	}{
+		{token.LET, 0, 1, 1},
+		{token.IDENT, 4, 1, 5},
+		{token.ASSIGN, 6, 1, 7},
+		{token.INT, 8, 1, 9},
+		{token.SEMICOLON, 9, 1, 10},
+		{token.IDENT, 13, 2, 3},
+		{token.LPAREN, 16, 2, 6},
+		{token.IDENT, 17, 2, 7},
+		{token.COMMA, 18, 2, 8},
+		{token.STRING, 20, 2, 10},
+		{token.RPAREN, 25, 2, 15},
+		{token.EOF, 27, 3, 1},
+		{token.EOF, 27, 3, 1},
+	}
+
+	l := newLexerWithFilename("test.monkey", input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Pos.Filename != "test.monkey" {
+			t.Fatalf("tests[%d] - filename wrong. got=%q", i, tok.Pos.Filename)
+		}
+		if tok.Pos.Offset != tt.expectedOffset || tok.Pos.Line != tt.expectedLine || tok.Pos.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - position wrong. expected=%d:%d (offset %d), got=%d:%d (offset %d)", i,
+				tt.expectedLine, tt.expectedColumn, tt.expectedOffset, tok.Pos.Line, tok.Pos.Column, tok.Pos.Offset)
+		}
+	}
+}
+
+func TestShebang(t *testing.T) {
+	input := "#!/usr/bin/env monkey\nlet x = 1;"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedLine    int
+	}{
+		{token.LET, "let", 2},
+		{token.IDENT, "x", 2},
+		{token.ASSIGN, "=", 2},
+		{token.INT, "1", 2},
+		{token.SEMICOLON, ";", 2},
+		{token.EOF, "", 2},
+	}
+
+	l := newLexer(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token wrong. expected=%q %q, got=%q %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+		if tok.Pos.Line != tt.expectedLine {
+			t.Fatalf("tests[%d] - line wrong. expected=%d, got=%d", i, tt.expectedLine, tok.Pos.Line)
+		}
+	}
+
+	// '#!' не на початку - звичайні ILLEGAL та BANG
+	l = newLexer("1 #!")
+	l.NextToken()
+	if tok := l.NextToken(); tok.Type != token.ILLEGAL {
+		t.Fatalf("expected ILLEGAL for '#' in the middle of input, got=%q", tok.Type)
+	}
+}
+
+func TestReaderError(t *testing.T) {
+	failure := errors.New("disk failure")
+	l := NewReader(io.MultiReader(strings.NewReader("let x"), iotest.ErrReader(failure)))
+
+	expected := []token.TokenType{token.LET, token.IDENT, token.EOF}
+	for i, tokType := range expected {
+		if tok := l.NextToken(); tok.Type != tokType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tokType, tok.Type)
+		}
+	}
+	if !errors.Is(l.Err(), failure) {
+		t.Fatalf("wrong Err. expected=%v, got=%v", failure, l.Err())
+	}
+}
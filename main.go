@@ -1,19 +1,147 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"interpreter/ast"
+	"interpreter/compiler"
+	"interpreter/diagnostic"
+	"interpreter/evaluator"
+	"interpreter/lexer"
+	"interpreter/object"
+	"interpreter/parser"
 	"interpreter/repl"
+	"interpreter/vm"
+	"io"
 	"os"
 	"os/user"
+	"strings"
 )
 
+const (
+	exitOK = 0
+	// помилка парсингу або виконання програми
+	exitFailure = 1
+	// неправильні аргументи або файл не читається
+	exitUsage = 2
+)
+
+const usage = `usage:
+  interpreter                 start the REPL, or run a program from stdin if it is not a terminal
+  interpreter run <file>      run a script file
+  interpreter -e '<program>'  evaluate a program and print its result
+
+flags:
+  -vm                         run with the bytecode compiler and virtual machine instead of the tree-walking evaluator
+`
+
 func main() {
-	user, err := user.Current()
-	if err != nil {
-		panic(err)
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("interpreter", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { fmt.Fprint(stderr, usage) }
+	expr := flags.String("e", "", "evaluate a program and print its result")
+	useVM := flags.Bool("vm", false, "run with the bytecode virtual machine")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	rest := flags.Args()
+
+	switch {
+	case *expr != "":
+		if len(rest) > 0 {
+			flags.Usage()
+			return exitUsage
+		}
+		return execute("<expr>", strings.NewReader(*expr), true, *useVM, stdout, stderr)
+	case len(rest) > 0 && rest[0] == "run":
+		if len(rest) != 2 {
+			flags.Usage()
+			return exitUsage
+		}
+		file, err := os.Open(rest[1])
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %s\n", err)
+			return exitUsage
+		}
+		defer file.Close()
+		return execute(rest[1], file, false, *useVM, stdout, stderr)
+	case len(rest) > 0:
+		flags.Usage()
+		return exitUsage
+	case !isTerminal(stdin):
+		return execute("<stdin>", stdin, false, *useVM, stdout, stderr)
+	default:
+		user, err := user.Current()
+		if err != nil {
+			panic(err)
+		}
+		fmt.Fprintf(stdout, "Hello %s! This is the Monkey programming language!\n", user.Username)
+		fmt.Fprintf(stdout, "Feel free to type in commands\n")
+		repl.Start(stdin, stdout)
+		return exitOK
+	}
+}
+
+// execute парсить і виконує програму з r. якщо printResult - друкує значення останнього виразу.
+// лексер читає r потоково, а прочитаний текст зберігається для показу помилок
+func execute(filename string, r io.Reader, printResult, useVM bool, stdout, stderr io.Writer) int {
+	var text strings.Builder
+	l := lexer.NewReaderWithFilename(filename, io.TeeReader(r, &text))
+	p := parser.New(l)
+	program := p.ParseProgram()
+	source := text.String()
+	if len(p.Errors) > 0 {
+		diagnostic.Render(stderr, source, p.Errors)
+		return exitFailure
+	}
+
+	var result object.Object
+	if useVM {
+		result = runVM(program)
+	} else {
+		result = evaluator.Eval(program, object.NewEnvironment())
+	}
+	if err, ok := result.(*object.Error); ok {
+		diagnostic.Render(stderr, source, []diagnostic.Diagnostic{diagnostic.FromRuntimeError(err)})
+		return exitFailure
+	}
+
+	if printResult && result != nil && result != evaluator.NULL {
+		fmt.Fprintln(stdout, result.Inspect())
+	}
+	return exitOK
+}
+
+// runVM компілює програму в байткод і виконує її. помилки повертаються як *object.Error
+func runVM(program *ast.Program) object.Object {
+	comp := compiler.New()
+	if err := comp.Compile(program); err != nil {
+		return object.NewError("%s", err)
 	}
-	fmt.Printf("Hello %s! This is the Monkey programming language!\n", user.Username)
-	fmt.Printf("Feel free to type in commands\n")
-	repl.Start(os.Stdin, os.Stdout)
 
+	machine := vm.New(comp.Bytecode())
+	if err := machine.Run(); err != nil {
+		if rtErr, ok := err.(*object.Error); ok {
+			return rtErr
+		}
+		return object.NewError("%s", err)
+	}
+	return machine.LastPoppedStackElem()
+}
+
+// isTerminal - чи підключений r до терміналу (тоді запускаємо REPL, а не читаємо програму)
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
 }
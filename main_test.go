@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "script.monkey")
+	err := os.WriteFile(script, []byte("#!/usr/bin/env interpreter\nlet x = 1;\nx + true;\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args           []string
+		stdin          string
+		expectedCode   int
+		expectedStdout string
+		expectedStderr string
+	}{
+		{[]string{"-e", "1 + 2"}, "", exitOK, "3\n", ""},
+		{[]string{"-vm", "-e", "let f = fn(x) { x * 2 }; f(21)"}, "", exitOK, "42\n", ""},
+		{[]string{"-vm", "-e", "-true"}, "", exitFailure, "", "error: unknown operator: -BOOLEAN"},
+		{[]string{"-e", "let x = 1;"}, "", exitOK, "", ""},
+		{[]string{"-e", "1 +"}, "", exitFailure, "", "error: no prefix parse function found for token type EOF found"},
+		{[]string{"-e", "-true"}, "", exitFailure, "", "error: unknown operator: -BOOLEAN"},
+		{[]string{"run", script}, "", exitFailure, "", " --> " + script + ":3:3"},
+		{[]string{"run", filepath.Join(dir, "missing.monkey")}, "", exitUsage, "", "error: open"},
+		{[]string{"run"}, "", exitUsage, "", "usage:"},
+		{[]string{"unknown"}, "", exitUsage, "", "usage:"},
+		{[]string{}, "let a = 5; a * 2;", exitOK, "", ""},
+		{[]string{}, "a", exitFailure, "", "error: identifier not found: a"},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		code := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+		if code != tt.expectedCode {
+			t.Errorf("args %q: wrong exit code. expected=%d, got=%d (stderr=%q)", tt.args, tt.expectedCode, code, stderr.String())
+		}
+		if stdout.String() != tt.expectedStdout {
+			t.Errorf("args %q: wrong stdout. expected=%q, got=%q", tt.args, tt.expectedStdout, stdout.String())
+		}
+		if !strings.Contains(stderr.String(), tt.expectedStderr) {
+			t.Errorf("args %q: stderr %q does not contain %q", tt.args, stderr.String(), tt.expectedStderr)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	stdin := io.MultiReader(strings.NewReader("let x = 1;\n"), iotest.ErrReader(errors.New("broken pipe")))
+	if code := run(nil, stdin, &stdout, &stderr); code != exitFailure {
+		t.Errorf("wrong exit code for failing stdin. expected=%d, got=%d", exitFailure, code)
+	}
+	if !strings.Contains(stderr.String(), "error: cannot read input: broken pipe") {
+		t.Errorf("stderr %q does not report the read error", stderr.String())
+	}
+}
@@ -0,0 +1,549 @@
+package monkey
+
+import (
+	"context"
+	"fmt"
+	"interpreter/evaluator"
+	"interpreter/object"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+)
+
+var (
+	objectType = reflect.TypeOf((*object.Object)(nil)).Elem()
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+	bigIntType = reflect.TypeOf(big.Int{})
+)
+
+// ToObject конвертує Go-значення в об'єкт скрипта:
+//
+//	nil, nil-вказівник       -> null
+//	bool                     -> BOOLEAN
+//	int*, uint*, big.Int     -> INTEGER
+//	float32, float64         -> FLOAT
+//	string                   -> STRING
+//	slice, array             -> ARRAY
+//	map                      -> HASH, ключі відсортовані
+//	struct                   -> HASH з експортованими полями в порядку оголошення
+//	func                     -> вбудована функція
+//
+// ім'я поля в хеші можна змінити тегом `monkey:"name"`, тег `monkey:"-"` пропускає поле.
+// object.Object повертається як є
+func ToObject(value any) (object.Object, error) {
+	return toObject(reflect.ValueOf(value))
+}
+
+func toObject(v reflect.Value) (object.Object, error) {
+	if !v.IsValid() {
+		return evaluator.NULL, nil
+	}
+	if v.Type().Implements(objectType) && v.CanInterface() {
+		if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+			return evaluator.NULL, nil
+		}
+		return v.Interface().(object.Object), nil
+	}
+
+	if v.Type() == bigIntType {
+		value := v.Interface().(big.Int)
+		return object.NewInteger(new(big.Int).Set(&value)), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return evaluator.TRUE, nil
+		}
+		return evaluator.FALSE, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &object.Integer{Value: v.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("integer %d overflows INTEGER", v.Uint())
+		}
+		return &object.Integer{Value: int64(v.Uint())}, nil
+	case reflect.Float32, reflect.Float64:
+		return &object.Float{Value: v.Float()}, nil
+	case reflect.String:
+		return &object.String{Value: v.String()}, nil
+	case reflect.Slice, reflect.Array:
+		elements := make([]object.Object, v.Len())
+		for i := range elements {
+			element, err := toObject(v.Index(i))
+			if err != nil {
+				return nil, fmt.Errorf("index %d: %w", i, err)
+			}
+			elements[i] = element
+		}
+		return &object.Array{Elements: elements}, nil
+	case reflect.Map:
+		return mapToHash(v)
+	case reflect.Struct:
+		return structToHash(v)
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return evaluator.NULL, nil
+		}
+		return toObject(v.Elem())
+	case reflect.Func:
+		if v.IsNil() {
+			return evaluator.NULL, nil
+		}
+		return wrapFunc(v)
+	default:
+		return nil, fmt.Errorf("cannot convert %s to a script value", v.Type())
+	}
+}
+
+func mapToHash(v reflect.Value) (object.Object, error) {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return lessKey(keys[i], keys[j]) })
+
+	hash := object.NewHash()
+	for _, key := range keys {
+		keyObj, err := toObject(key)
+		if err != nil {
+			return nil, err
+		}
+		hashable, ok := keyObj.(object.Hashable)
+		if !ok {
+			return nil, fmt.Errorf("unusable as hash key: %s", keyObj.Type())
+		}
+		value, err := toObject(v.MapIndex(key))
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", keyObj.Inspect(), err)
+		}
+		hash.Set(hashable, value)
+	}
+	return hash, nil
+}
+
+// lessKey впорядковує ключі мапи, щоб порядок у хеші не залежав від обходу мапи в Go
+func lessKey(a, b reflect.Value) bool {
+	for a.Kind() == reflect.Interface || a.Kind() == reflect.Pointer {
+		if a.IsNil() {
+			return !b.IsNil()
+		}
+		a = a.Elem()
+	}
+	for b.Kind() == reflect.Interface || b.Kind() == reflect.Pointer {
+		if b.IsNil() {
+			return false
+		}
+		b = b.Elem()
+	}
+	if a.Kind() != b.Kind() {
+		return a.Kind() < b.Kind()
+	}
+
+	switch a.Kind() {
+	case reflect.Bool:
+		return !a.Bool() && b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() < b.Uint()
+	case reflect.String:
+		return a.String() < b.String()
+	default:
+		return fmt.Sprint(a) < fmt.Sprint(b)
+	}
+}
+
+func structToHash(v reflect.Value) (object.Object, error) {
+	hash := object.NewHash()
+	for i := 0; i < v.NumField(); i++ {
+		name, ok := fieldName(v.Type().Field(i))
+		if !ok {
+			continue
+		}
+		value, err := toObject(v.Field(i))
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", name, err)
+		}
+		hash.Set(&object.String{Value: name}, value)
+	}
+	return hash, nil
+}
+
+// fieldName повертає ключ хешу для поля структури. неекспортовані поля і поля з тегом "-" пропускаються
+func fieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	switch tag := field.Tag.Get("monkey"); tag {
+	case "-":
+		return "", false
+	case "":
+		return field.Name, true
+	default:
+		return tag, true
+	}
+}
+
+// wrapFunc перетворює Go-функцію на вбудовану. Аргументи декодуються в типи параметрів,
+// результат може бути (), (T), (error) або (T, error). Непорожня error стає помилкою скрипта
+func wrapFunc(fn reflect.Value) (object.Object, error) {
+	t := fn.Type()
+	numOut := t.NumOut()
+	returnsError := numOut > 0 && t.Out(numOut-1) == errorType
+	if numOut > 2 || (numOut == 2 && !returnsError) {
+		return nil, fmt.Errorf("cannot convert %s to a script value: want results (), (T), (error) or (T, error)", t)
+	}
+
+	builtin := &object.Builtin{}
+	builtin.CallerFn = func(caller object.Caller, args ...object.Object) (result object.Object) {
+		// функція скрипта, декодована в Go-функцію без результату error, панікує помилкою скрипта
+		defer func() {
+			if r := recover(); r != nil {
+				err, ok := r.(*object.Error)
+				if !ok {
+					panic(r)
+				}
+				result = err
+			}
+		}()
+
+		numIn := t.NumIn()
+		if t.IsVariadic() {
+			if len(args) < numIn-1 {
+				return object.NewError("wrong number of arguments. got=%d, want at least %d", len(args), numIn-1)
+			}
+		} else if len(args) != numIn {
+			return object.NewError("wrong number of arguments. got=%d, want=%d", len(args), numIn)
+		}
+
+		in := make([]reflect.Value, len(args))
+		for i, arg := range args {
+			var paramType reflect.Type
+			if t.IsVariadic() && i >= numIn-1 {
+				paramType = t.In(numIn - 1).Elem()
+			} else {
+				paramType = t.In(i)
+			}
+			in[i] = reflect.New(paramType).Elem()
+			if err := (decoder{ctx: context.Background(), call: caller}).decode(arg, in[i]); err != nil {
+				return object.NewError("argument %d to `%s`: %s", i+1, builtin.Name, err)
+			}
+		}
+
+		out := fn.Call(in)
+		if returnsError {
+			if err := out[numOut-1]; !err.IsNil() {
+				return object.NewError("%s", err.Interface().(error))
+			}
+			out = out[:numOut-1]
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		result, err := toObject(out[0])
+		if err != nil {
+			return object.NewError("result of `%s`: %s", builtin.Name, err)
+		}
+		return result
+	}
+	// рушій без CallerFn (vm) викликає функції скрипта в окремому запуску без обмежень
+	builtin.Fn = func(args ...object.Object) object.Object {
+		return builtin.CallerFn(nil, args...)
+	}
+	return builtin, nil
+}
+
+// FromObject конвертує об'єкт скрипта в Go-значення:
+//
+//	null      -> nil
+//	INTEGER   -> int64, або *big.Int, якщо не влазить в int64
+//	FLOAT     -> float64
+//	BOOLEAN   -> bool
+//	STRING    -> string
+//	ARRAY     -> []any
+//	HASH      -> map[string]any, якщо всі ключі рядки, інакше map[any]any
+//
+// функції та помилки повертаються як є
+func FromObject(obj object.Object) any {
+	switch obj := obj.(type) {
+	case nil, *object.Null:
+		return nil
+	case *object.Integer:
+		return obj.Value
+	case *object.BigInteger:
+		return new(big.Int).Set(obj.Value)
+	case *object.Float:
+		return obj.Value
+	case *object.Boolean:
+		return obj.Value
+	case *object.String:
+		return obj.Value
+	case *object.Array:
+		elements := make([]any, len(obj.Elements))
+		for i, element := range obj.Elements {
+			elements[i] = FromObject(element)
+		}
+		return elements
+	case *object.Hash:
+		pairs := obj.OrderedPairs()
+		if allStringKeys(pairs) {
+			m := make(map[string]any, len(pairs))
+			for _, pair := range pairs {
+				m[pair.Key.(*object.String).Value] = FromObject(pair.Value)
+			}
+			return m
+		}
+		m := make(map[any]any, len(pairs))
+		for _, pair := range pairs {
+			m[FromObject(pair.Key)] = FromObject(pair.Value)
+		}
+		return m
+	case *object.ReturnValue:
+		return FromObject(obj.Value)
+	default:
+		return obj
+	}
+}
+
+func allStringKeys(pairs []object.HashPair) bool {
+	for _, pair := range pairs {
+		if _, ok := pair.Key.(*object.String); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Decode записує об'єкт скрипта в target, який має бути ненульовим вказівником.
+// хеш декодується в структуру за іменами полів (з урахуванням тегу `monkey`), відсутні ключі
+// залишають поле без змін, null дає нульове значення. Функцію скрипта можна декодувати
+// в Go-функцію: аргументи і результат конвертуються так само, як у ToObject і Decode.
+// така функція виконується без обмежень; щоб на неї діяли Options інтерпретатора,
+// декодуйте через Interpreter.GetInto
+func Decode(obj object.Object, target any) error {
+	return decoder{ctx: context.Background()}.decodeTarget(obj, target)
+}
+
+// decoder - контекст і обмеження, з якими викликаються функції скрипта, декодовані в Go-функції
+type decoder struct {
+	ctx  context.Context
+	opts evaluator.Options
+	// call, якщо задана, викликає функції скрипта в запуску, що передав їх функції хоста
+	call object.Caller
+}
+
+func (d decoder) apply(fn object.Object, args []object.Object) object.Object {
+	if d.call != nil {
+		return d.call(fn, args)
+	}
+	return evaluator.ApplyFunctionContext(d.ctx, fn, args, d.opts)
+}
+
+func (d decoder) decodeTarget(obj object.Object, target any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("monkey: Decode target must be a non-nil pointer, got %T", target)
+	}
+	if err := d.decode(obj, v.Elem()); err != nil {
+		return fmt.Errorf("monkey: %w", err)
+	}
+	return nil
+}
+
+func (d decoder) decode(obj object.Object, v reflect.Value) error {
+	if obj == nil {
+		obj = evaluator.NULL
+	}
+	if v.Type() == objectType {
+		v.Set(reflect.ValueOf(obj))
+		return nil
+	}
+	if _, ok := obj.(*object.Null); ok {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	if v.Type() == bigIntType {
+		switch integer := obj.(type) {
+		case *object.Integer:
+			v.Set(reflect.ValueOf(*big.NewInt(integer.Value)))
+			return nil
+		case *object.BigInteger:
+			v.Set(reflect.ValueOf(*new(big.Int).Set(integer.Value)))
+			return nil
+		}
+	}
+	if integer, ok := obj.(*object.BigInteger); ok && v.Kind() >= reflect.Int && v.Kind() <= reflect.Uintptr {
+		return fmt.Errorf("integer %s overflows %s", integer.Value, v.Type())
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			break
+		}
+		if value := FromObject(obj); value != nil {
+			v.Set(reflect.ValueOf(value))
+		}
+		return nil
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		if err := d.decode(obj, elem.Elem()); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	case reflect.Bool:
+		if b, ok := obj.(*object.Boolean); ok {
+			v.SetBool(b.Value)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, ok := obj.(*object.Integer); ok {
+			if v.OverflowInt(i.Value) {
+				return fmt.Errorf("integer %d overflows %s", i.Value, v.Type())
+			}
+			v.SetInt(i.Value)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if i, ok := obj.(*object.Integer); ok {
+			if i.Value < 0 || v.OverflowUint(uint64(i.Value)) {
+				return fmt.Errorf("integer %d overflows %s", i.Value, v.Type())
+			}
+			v.SetUint(uint64(i.Value))
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		// ціле підходить і для дробового поля
+		if f, ok := object.FloatValue(obj); ok {
+			v.SetFloat(f)
+			return nil
+		}
+	case reflect.String:
+		if s, ok := obj.(*object.String); ok {
+			v.SetString(s.Value)
+			return nil
+		}
+	case reflect.Slice:
+		if arr, ok := obj.(*object.Array); ok {
+			slice := reflect.MakeSlice(v.Type(), len(arr.Elements), len(arr.Elements))
+			if err := d.decodeElements(arr, slice); err != nil {
+				return err
+			}
+			v.Set(slice)
+			return nil
+		}
+	case reflect.Array:
+		if arr, ok := obj.(*object.Array); ok {
+			if len(arr.Elements) != v.Len() {
+				return fmt.Errorf("cannot use ARRAY of length %d as %s", len(arr.Elements), v.Type())
+			}
+			return d.decodeElements(arr, v)
+		}
+	case reflect.Map:
+		if hash, ok := obj.(*object.Hash); ok {
+			m := reflect.MakeMapWithSize(v.Type(), len(hash.Pairs))
+			for _, pair := range hash.OrderedPairs() {
+				key := reflect.New(v.Type().Key()).Elem()
+				if err := d.decode(pair.Key, key); err != nil {
+					return fmt.Errorf("key %s: %w", pair.Key.Inspect(), err)
+				}
+				value := reflect.New(v.Type().Elem()).Elem()
+				if err := d.decode(pair.Value, value); err != nil {
+					return fmt.Errorf("key %s: %w", pair.Key.Inspect(), err)
+				}
+				m.SetMapIndex(key, value)
+			}
+			v.Set(m)
+			return nil
+		}
+	case reflect.Struct:
+		if hash, ok := obj.(*object.Hash); ok {
+			for i := 0; i < v.NumField(); i++ {
+				name, ok := fieldName(v.Type().Field(i))
+				if !ok {
+					continue
+				}
+				value, ok := hash.Get(&object.String{Value: name})
+				if !ok {
+					continue
+				}
+				if err := d.decode(value, v.Field(i)); err != nil {
+					return fmt.Errorf("field %s: %w", name, err)
+				}
+			}
+			return nil
+		}
+	case reflect.Func:
+		switch obj.(type) {
+		case *object.Function, *object.Builtin:
+			return d.decodeFunc(obj, v)
+		}
+	}
+
+	return fmt.Errorf("cannot use %s as %s", obj.Type(), v.Type())
+}
+
+func (d decoder) decodeElements(arr *object.Array, v reflect.Value) error {
+	for i, element := range arr.Elements {
+		if err := d.decode(element, v.Index(i)); err != nil {
+			return fmt.Errorf("index %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// decodeFunc робить Go-функцію, яка викликає fn. Помилка скрипта повертається через
+// останній результат типу error, а якщо його немає - стає панікою
+func (d decoder) decodeFunc(fn object.Object, v reflect.Value) error {
+	t := v.Type()
+	numOut := t.NumOut()
+	returnsError := numOut > 0 && t.Out(numOut-1) == errorType
+	if numOut > 2 || (numOut == 2 && !returnsError) {
+		return fmt.Errorf("cannot use %s as %s: want results (), (T), (error) or (T, error)", fn.Type(), t)
+	}
+
+	call := func(in []reflect.Value) []reflect.Value {
+		out := make([]reflect.Value, numOut)
+		for i := range out {
+			out[i] = reflect.New(t.Out(i)).Elem()
+		}
+		fail := func(err error) []reflect.Value {
+			if !returnsError {
+				panic(err)
+			}
+			out[numOut-1] = reflect.ValueOf(&err).Elem()
+			return out
+		}
+
+		if t.IsVariadic() && len(in) > 0 {
+			variadic := in[len(in)-1]
+			in = in[:len(in)-1]
+			for i := 0; i < variadic.Len(); i++ {
+				in = append(in, variadic.Index(i))
+			}
+		}
+		args := make([]object.Object, len(in))
+		for i, arg := range in {
+			obj, err := toObject(arg)
+			if err != nil {
+				return fail(fmt.Errorf("monkey: argument %d: %w", i+1, err))
+			}
+			args[i] = obj
+		}
+
+		result := d.apply(fn, args)
+		if err, ok := result.(*object.Error); ok {
+			return fail(err)
+		}
+		if numOut == 0 || (numOut == 1 && returnsError) {
+			return out
+		}
+		if err := d.decode(result, out[0]); err != nil {
+			return fail(fmt.Errorf("monkey: result: %w", err))
+		}
+		return out
+	}
+
+	v.Set(reflect.MakeFunc(t, call))
+	return nil
+}
@@ -0,0 +1,138 @@
+// Package monkey - API для вбудовування інтерпретатора в Go-програми.
+// значення між Go і скриптом конвертуються автоматично, див. ToObject і Decode
+package monkey
+
+import (
+	"context"
+	"fmt"
+	"interpreter/diagnostic"
+	"interpreter/evaluator"
+	"interpreter/lexer"
+	"interpreter/object"
+	"interpreter/parser"
+	"strings"
+)
+
+// Interpreter тримає глобальне середовище між викликами Eval, тому змінні й функції,
+// оголошені в одному фрагменті, доступні в наступних. Interpreter не можна використовувати
+// з кількох горутин одночасно
+type Interpreter struct {
+	env  *object.Environment
+	opts evaluator.Options
+}
+
+func New() *Interpreter {
+	return NewWithOptions(evaluator.Options{})
+}
+
+// NewWithOptions створює інтерпретатор, кожен виклик Eval і Call якого обмежено opts
+func NewWithOptions(opts evaluator.Options) *Interpreter {
+	return &Interpreter{env: object.NewEnvironment(), opts: opts}
+}
+
+// ParseError повертається з Eval, якщо код не розібрався
+type ParseError struct {
+	Diagnostics []diagnostic.Diagnostic
+}
+
+func (e *ParseError) Error() string {
+	messages := make([]string, 0, len(e.Diagnostics))
+	for _, d := range e.Diagnostics {
+		messages = append(messages, d.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Eval виконує src у глобальному середовищі і повертає результат останнього виразу як Go-значення.
+// помилка виконання, зокрема скасування ctx чи вихід за обмеження, повертається як *object.Error,
+// помилка розбору - як *ParseError
+func (in *Interpreter) Eval(ctx context.Context, src string) (any, error) {
+	p := parser.New(lexer.New(src))
+	// константи з попередніх Eval не можна змінити чи оголосити знову; змінні з Eval
+	// і значення з Set скрипт може перевизначити через let
+	for _, name := range in.env.Names() {
+		if binding, _ := in.env.Lookup(name); binding.Constant {
+			p.Declare(name, true)
+		}
+	}
+	program := p.ParseProgram()
+	if len(p.Errors) > 0 {
+		return nil, &ParseError{Diagnostics: p.Errors}
+	}
+
+	return result(evaluator.EvalContext(ctx, program, in.env, in.opts))
+}
+
+// Set конвертує value через ToObject і зв'язує його з name у глобальному середовищі.
+// Go-функція стає вбудованою функцією з цим ім'ям
+func (in *Interpreter) Set(name string, value any) error {
+	obj, err := ToObject(value)
+	if err != nil {
+		return fmt.Errorf("monkey: set %s: %w", name, err)
+	}
+	if builtin, ok := obj.(*object.Builtin); ok && builtin.Name == "" {
+		builtin.Name = name
+	}
+
+	in.env.Set(name, obj)
+	return nil
+}
+
+// Get повертає значення глобальної змінної, сконвертоване через FromObject
+func (in *Interpreter) Get(name string) (any, bool) {
+	obj, ok := in.env.Get(name)
+	if !ok {
+		return nil, false
+	}
+	return FromObject(obj), true
+}
+
+// GetInto декодує глобальну змінну name у target, який має бути вказівником, див. Decode.
+// функції скрипта, декодовані в Go-функції, виконуються з Options інтерпретатора
+func (in *Interpreter) GetInto(name string, target any) error {
+	return in.GetIntoContext(context.Background(), name, target)
+}
+
+// GetIntoContext - GetInto, де декодовані функції зупиняються зі скасуванням ctx
+func (in *Interpreter) GetIntoContext(ctx context.Context, name string, target any) error {
+	obj, ok := in.env.Get(name)
+	if !ok {
+		return fmt.Errorf("monkey: identifier not found: %s", name)
+	}
+	return decoder{ctx: ctx, opts: in.opts}.decodeTarget(obj, target)
+}
+
+// Call викликає функцію скрипта або вбудовану функцію fnName з аргументами,
+// сконвертованими через ToObject, і повертає результат як Go-значення
+func (in *Interpreter) Call(fnName string, args ...any) (any, error) {
+	return in.CallContext(context.Background(), fnName, args...)
+}
+
+// CallContext - Call, який зупиняється зі скасуванням ctx
+func (in *Interpreter) CallContext(ctx context.Context, fnName string, args ...any) (any, error) {
+	objects := make([]object.Object, len(args))
+	for i, arg := range args {
+		obj, err := ToObject(arg)
+		if err != nil {
+			return nil, fmt.Errorf("monkey: argument %d to %s: %w", i+1, fnName, err)
+		}
+		objects[i] = obj
+	}
+
+	fn, ok := in.env.Get(fnName)
+	if !ok {
+		builtin, ok := object.LookupBuiltin(fnName)
+		if !ok {
+			return nil, fmt.Errorf("monkey: identifier not found: %s", fnName)
+		}
+		fn = builtin
+	}
+	return result(evaluator.ApplyFunctionContext(ctx, fn, objects, in.opts))
+}
+
+func result(obj object.Object) (any, error) {
+	if err, ok := obj.(*object.Error); ok {
+		return nil, err
+	}
+	return FromObject(obj), nil
+}
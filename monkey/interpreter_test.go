@@ -0,0 +1,472 @@
+package monkey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"interpreter/evaluator"
+	"interpreter/lexer"
+	"interpreter/object"
+	"interpreter/parser"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type point struct {
+	X, Y   int
+	Label  string `monkey:"label"`
+	Hidden bool   `monkey:"-"`
+	secret int
+}
+
+func TestEval(t *testing.T) {
+	in := New()
+
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"let x = 5; x * 2", int64(10)},
+		{"x + 1", int64(6)},
+		{`"mon" + "key"`, "monkey"},
+		{`[1, true, "x"]`, []any{int64(1), true, "x"}},
+		{`{"a": 1, "b": [2]}`, map[string]any{"a": int64(1), "b": []any{int64(2)}}},
+		{`{1: "one", true: "yes"}`, map[any]any{int64(1): "one", true: "yes"}},
+		{"let y = 1;", nil},
+	}
+
+	for _, tt := range tests {
+		got, err := in.Eval(context.Background(), tt.input)
+		if err != nil {
+			t.Errorf("Eval(%q) returned error: %s", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Eval(%q) = %#v, want %#v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	in := New()
+
+	_, err := in.Eval(context.Background(), "let = 5;")
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || len(parseErr.Diagnostics) == 0 {
+		t.Errorf("expected *ParseError, got %T (%v)", err, err)
+	}
+
+	_, err = in.Eval(context.Background(), "1 + true")
+	var runtimeErr *object.Error
+	if !errors.As(err, &runtimeErr) || runtimeErr.Message != "type mismatch: INTEGER + BOOLEAN" {
+		t.Errorf("expected runtime error, got %T (%v)", err, err)
+	}
+
+	// оголошення з попереднього Eval враховуються під час розбору
+	if _, err := in.Eval(context.Background(), "const limit = 10;"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = in.Eval(context.Background(), "limit = 11;")
+	if err == nil || err.Error() != "1:1: cannot assign to constant: limit" {
+		t.Errorf("wrong error for constant assignment: %v", err)
+	}
+	if _, err := in.Eval(context.Background(), "let limit = 11;"); err == nil {
+		t.Errorf("expected redeclaration of a constant to fail")
+	}
+
+	// змінні з попереднього Eval і значення з Set можна оголосити знову
+	if err := in.Set("port", 80); err != nil {
+		t.Fatal(err)
+	}
+	got, err := in.Eval(context.Background(), "let port = port + 1; let port2 = 1; port")
+	if err != nil || got != int64(81) {
+		t.Errorf("re-let of a host value failed: %v, %v", got, err)
+	}
+	if _, err := in.Eval(context.Background(), "let port2 = 2;"); err != nil {
+		t.Errorf("re-let of a variable from an earlier Eval failed: %v", err)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	in := NewWithOptions(evaluator.Options{MaxSteps: 1000})
+	if _, err := in.Eval(context.Background(), "let loop = fn(n) { loop(n + 1) };"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := in.Call("loop", 0)
+	if err == nil || err.Error() != "1:29: step limit exceeded: 1000 steps" {
+		t.Errorf("wrong error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := in.Eval(ctx, "1"); err == nil || err.Error() != "execution cancelled: context canceled" {
+		t.Errorf("wrong error: %v", err)
+	}
+	if _, err := in.CallContext(ctx, "loop", 0); err == nil || err.Error() != "execution cancelled: context canceled" {
+		t.Errorf("wrong error: %v", err)
+	}
+
+	var loop func(int) (int, error)
+	if err := in.GetInto("loop", &loop); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loop(0); err == nil || err.Error() != "1:29: step limit exceeded: 1000 steps" {
+		t.Errorf("wrong error: %v", err)
+	}
+	if err := in.GetIntoContext(ctx, "loop", &loop); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loop(0); err == nil || err.Error() != "execution cancelled: context canceled" {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
+func TestSetAndGet(t *testing.T) {
+	in := New()
+
+	values := map[string]any{
+		"n":     42,
+		"u":     uint8(7),
+		"ok":    true,
+		"name":  "monkey",
+		"list":  []string{"a", "b"},
+		"arr":   [2]int{1, 2},
+		"ages":  map[string]int{"bob": 30, "al": 40},
+		"p":     point{X: 1, Y: 2, Label: "origin", Hidden: true, secret: 3},
+		"ptr":   &point{X: 5},
+		"empty": (*point)(nil),
+	}
+	for name, value := range values {
+		if err := in.Set(name, value); err != nil {
+			t.Fatalf("Set(%q) returned error: %s", name, err)
+		}
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"n + 1", "43"},
+		{"u", "7"},
+		{"if (ok) { name }", "monkey"},
+		{`list[1] + "!"`, "b!"},
+		{"arr", "[1, 2]"},
+		{"ages", "{al: 40, bob: 30}"},
+		{"p", "{X: 1, Y: 2, label: origin}"},
+		{`ptr["X"]`, "5"},
+		{"empty", "null"},
+	}
+
+	for _, tt := range tests {
+		obj := evalObject(t, in, tt.input)
+		if obj.Inspect() != tt.expected {
+			t.Errorf("%s = %s, want %s", tt.input, obj.Inspect(), tt.expected)
+		}
+	}
+
+	got, ok := in.Get("list")
+	if !ok || !reflect.DeepEqual(got, []any{"a", "b"}) {
+		t.Errorf("Get(list) = %#v, %v", got, ok)
+	}
+	if _, ok := in.Get("missing"); ok {
+		t.Errorf("Get(missing) reported ok")
+	}
+}
+
+func TestSetUnsupported(t *testing.T) {
+	in := New()
+
+	tests := []struct {
+		value    any
+		expected string
+	}{
+		{make(chan int), "monkey: set v: cannot convert chan int to a script value"},
+		{uint64(1 << 63), "monkey: set v: integer 9223372036854775808 overflows INTEGER"},
+		{func() (int, int) { return 0, 0 }, "monkey: set v: cannot convert func() (int, int) to a script value: want results (), (T), (error) or (T, error)"},
+	}
+
+	for _, tt := range tests {
+		err := in.Set("v", tt.value)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("wrong error. want=%q, got=%v", tt.expected, err)
+		}
+	}
+}
+
+func TestGetInto(t *testing.T) {
+	in := New()
+	if _, err := in.Eval(context.Background(), `
+		let p = {"X": 3, "Y": -4, "label": "corner", "Hidden": true, "extra": 1};
+		let ports = [80, 443];
+		let env = {"HOME": "/root"};
+		let big = 1000;
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	var p point
+	if err := in.GetInto("p", &p); err != nil {
+		t.Fatal(err)
+	}
+	if p != (point{X: 3, Y: -4, Label: "corner"}) {
+		t.Errorf("wrong point: %+v", p)
+	}
+
+	var ports []uint16
+	if err := in.GetInto("ports", &ports); err != nil || !reflect.DeepEqual(ports, []uint16{80, 443}) {
+		t.Errorf("wrong ports: %v (%v)", ports, err)
+	}
+
+	var env map[string]string
+	if err := in.GetInto("env", &env); err != nil || env["HOME"] != "/root" {
+		t.Errorf("wrong env: %v (%v)", env, err)
+	}
+
+	errorTests := []struct {
+		name     string
+		target   any
+		expected string
+	}{
+		{"big", new(int8), "monkey: integer 1000 overflows int8"},
+		{"p", new([]int), "monkey: cannot use HASH as []int"},
+		{"p", new(struct{ X string }), "monkey: field X: cannot use INTEGER as string"},
+		{"ports", new([3]int), "monkey: cannot use ARRAY of length 2 as [3]int"},
+		{"missing", new(int), "monkey: identifier not found: missing"},
+		{"big", 0, "monkey: Decode target must be a non-nil pointer, got int"},
+	}
+
+	for _, tt := range errorTests {
+		err := in.GetInto(tt.name, tt.target)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("GetInto(%q) wrong error. want=%q, got=%v", tt.name, tt.expected, err)
+		}
+	}
+}
+
+func TestCall(t *testing.T) {
+	in := New()
+	if _, err := in.Eval(context.Background(), `
+		let add = fn(a, b) { a + b };
+		let greet = fn(p) { "hello " + p["label"] };
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		fn       string
+		args     []any
+		expected any
+	}{
+		{"add", []any{2, 3}, int64(5)},
+		{"add", []any{"a", "b"}, "ab"},
+		{"greet", []any{point{Label: "world"}}, "hello world"},
+		{"len", []any{[]int{1, 2, 3}}, int64(3)},
+	}
+
+	for _, tt := range tests {
+		got, err := in.Call(tt.fn, tt.args...)
+		if err != nil {
+			t.Errorf("Call(%q) returned error: %s", tt.fn, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("Call(%q) = %#v, want %#v", tt.fn, got, tt.expected)
+		}
+	}
+
+	if _, err := in.Call("add", 1); err == nil || err.Error() != "wrong number of arguments: want=2, got=1" {
+		t.Errorf("wrong error: %v", err)
+	}
+	if _, err := in.Call("nope"); err == nil || err.Error() != "monkey: identifier not found: nope" {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
+func TestGoFunctions(t *testing.T) {
+	in := New()
+
+	var logged []string
+	funcs := map[string]any{
+		"upper": strings.ToUpper,
+		"log":   func(s string) { logged = append(logged, s) },
+		"sum": func(nums ...int) int {
+			total := 0
+			for _, n := range nums {
+				total += n
+			}
+			return total
+		},
+		"div": func(a, b int) (int, error) {
+			if b == 0 {
+				return 0, errors.New("division by zero")
+			}
+			return a / b, nil
+		},
+		"describe": func(p point) string { return fmt.Sprintf("%s@%d,%d", p.Label, p.X, p.Y) },
+	}
+	for name, fn := range funcs {
+		if err := in.Set(name, fn); err != nil {
+			t.Fatalf("Set(%q) returned error: %s", name, err)
+		}
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`upper("monkey")`, "MONKEY"},
+		{`log("hi")`, "null"},
+		{"sum()", "0"},
+		{"sum(1, 2, 3)", "6"},
+		{"div(7, 2)", "3"},
+		{`describe({"X": 1, "Y": 2, "label": "p"})`, "p@1,2"},
+		{"upper", "builtin function upper"},
+		{"div(1, 0)", "ERROR: 1:4: division by zero"},
+		{"upper(1)", "ERROR: 1:6: argument 1 to `upper`: cannot use INTEGER as string"},
+		{"div(1)", "ERROR: 1:4: wrong number of arguments. got=1, want=2"},
+	}
+
+	for _, tt := range tests {
+		obj := evalObject(t, in, tt.input)
+		if obj.Inspect() != tt.expected {
+			t.Errorf("%s = %s, want %s", tt.input, obj.Inspect(), tt.expected)
+		}
+	}
+
+	if !reflect.DeepEqual(logged, []string{"hi"}) {
+		t.Errorf("log was not called: %v", logged)
+	}
+}
+
+func TestHostCallbackLimits(t *testing.T) {
+	// функції скрипта, передані функції хоста, виконуються в тому ж запуску
+	in := NewWithOptions(evaluator.Options{MaxSteps: 10000})
+	if err := in.Set("apply", func(f func() int) int { return f() }); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"apply(fn() { let i = 0; while (i < 3000000) { i += 1; } i })", "step limit exceeded: 10000 steps"},
+		{"let g = fn() { apply(g) }; g()", "stack overflow"},
+	}
+
+	for _, tt := range tests {
+		_, err := in.Eval(context.Background(), tt.input)
+		if err == nil || !strings.HasSuffix(err.Error(), tt.expected) {
+			t.Errorf("%q: wrong error. want=%q, got=%v", tt.input, tt.expected, err)
+		}
+	}
+
+	in = New()
+	if err := in.Set("apply", func(f func() int) int { return f() }); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err := in.Eval(ctx, "apply(fn() { while (true) { } 1 })")
+	if err == nil || !strings.HasSuffix(err.Error(), "execution cancelled: context deadline exceeded") {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
+func TestDecodeFunction(t *testing.T) {
+	in := New()
+	if _, err := in.Eval(context.Background(), `
+		let add = fn(a, b) { a + b };
+		let fail = fn() { 1 + true };
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	var add func(int, int) int
+	if err := in.GetInto("add", &add); err != nil {
+		t.Fatal(err)
+	}
+	if got := add(2, 40); got != 42 {
+		t.Errorf("add(2, 40) = %d", got)
+	}
+
+	var fail func() (int, error)
+	if err := in.GetInto("fail", &fail); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fail(); err == nil || err.Error() != "3:23: type mismatch: INTEGER + BOOLEAN" {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
+// evalObject виконує input без конвертації результату, щоб порівнювати його через Inspect
+func evalObject(t *testing.T, in *Interpreter, input string) object.Object {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors) > 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors)
+	}
+	return evaluator.Eval(program, in.env)
+}
+
+func TestBigIntegers(t *testing.T) {
+	in := NewWithOptions(evaluator.Options{Arithmetic: evaluator.ArithmeticBig})
+
+	huge, _ := new(big.Int).SetString("100000000000000000000", 10)
+	if err := in.Set("huge", huge); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := in.Eval(context.Background(), "huge * 10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := got.(*big.Int); !ok || n.String() != "1000000000000000000000" {
+		t.Errorf("wrong result: %#v", got)
+	}
+
+	got, err = in.Eval(context.Background(), "huge / 100000000000")
+	if err != nil || got != int64(1000000000) {
+		t.Errorf("big result that fits int64 must become int64, got %#v (%v)", got, err)
+	}
+
+	var n big.Int
+	if err := in.GetInto("huge", &n); err != nil || n.Cmp(huge) != 0 {
+		t.Errorf("wrong big.Int: %s (%v)", n.String(), err)
+	}
+	var small int64
+	if err := in.GetInto("huge", &small); err == nil || err.Error() != "monkey: integer 100000000000000000000 overflows int64" {
+		t.Errorf("wrong error: %v", err)
+	}
+
+	if _, err := New().Eval(context.Background(), "9223372036854775807 + 1"); err == nil || err.Error() != "1:21: integer overflow: 9223372036854775807 + 1" {
+		t.Errorf("default interpreter must reject overflow, got %v", err)
+	}
+}
+
+func TestFloats(t *testing.T) {
+	in := New()
+	if err := in.Set("ratio", float32(0.5)); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := in.Eval(context.Background(), "ratio * 3")
+	if err != nil || got != 1.5 {
+		t.Errorf("wrong result: %#v (%v)", got, err)
+	}
+
+	var config struct {
+		Scale float64
+		Ratio float32
+	}
+	if _, err := in.Eval(context.Background(), `let config = {"Scale": 2, "Ratio": 0.25};`); err != nil {
+		t.Fatal(err)
+	}
+	if err := in.GetInto("config", &config); err != nil || config.Scale != 2 || config.Ratio != 0.25 {
+		t.Errorf("wrong config: %+v (%v)", config, err)
+	}
+}
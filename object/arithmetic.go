@@ -0,0 +1,76 @@
+package object
+
+import (
+	"math"
+	"math/big"
+)
+
+// IntegerOperation обчислює a operator b для + - * / % & | ^ << >> без переповнення.
+// ok=false означає, що результат не влазить в int64. ділення на нуль і від'ємний
+// зсув перевіряє викликач
+func IntegerOperation(operator string, a, b int64) (result int64, ok bool) {
+	switch operator {
+	case "+":
+		result = a + b
+		return result, (result > a) == (b > 0)
+	case "-":
+		result = a - b
+		return result, (result < a) == (b > 0)
+	case "*":
+		if a == 0 || b == 0 {
+			return 0, true
+		}
+		if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+			return 0, false
+		}
+		result = a * b
+		return result, result/b == a
+	case "/":
+		if a == math.MinInt64 && b == -1 {
+			return 0, false
+		}
+		return a / b, true
+	case "%":
+		// math.MinInt64 % -1 у Go дає 0 без паніки
+		return a % b, true
+	case "&":
+		return a & b, true
+	case "|":
+		return a | b, true
+	case "^":
+		return a ^ b, true
+	case "<<":
+		if b >= 64 {
+			return 0, a == 0
+		}
+		result = a << b
+		return result, result>>b == a
+	case ">>":
+		// арифметичний зсув: для b >= 64 дає 0 або -1
+		return a >> b, true
+	}
+	panic("object: IntegerOperation called with operator " + operator)
+}
+
+// FloatValue повертає значення числа як float64. для нечислових об'єктів ok=false
+func FloatValue(obj Object) (value float64, ok bool) {
+	switch obj := obj.(type) {
+	case *Float:
+		return obj.Value, true
+	case *Integer:
+		return float64(obj.Value), true
+	case *BigInteger:
+		value, _ = new(big.Float).SetInt(obj.Value).Float64()
+		return value, true
+	}
+	return 0, false
+}
+
+// IsNumber - чи є об'єкт цілим або дробовим числом
+func IsNumber(obj Object) bool {
+	switch obj.Type() {
+	case INTEGER_OBJ, FLOAT_OBJ:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,258 @@
+package object
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// BuiltinOutput - куди пише puts
+var BuiltinOutput io.Writer = os.Stdout
+
+// реєстр вбудованих функцій, спільний для evaluator і vm
+var (
+	builtinsMu sync.RWMutex
+	builtins   = map[string]*Builtin{}
+)
+
+// RegisterBuiltin додає нативну функцію під іменем name. Повторна реєстрація замінює попередню.
+// функція може повернути nil - це означає null
+func RegisterBuiltin(name string, fn BuiltinFunction) {
+	if fn == nil {
+		panic("object: RegisterBuiltin called with nil function for " + name)
+	}
+
+	builtinsMu.Lock()
+	defer builtinsMu.Unlock()
+	builtins[name] = &Builtin{Name: name, Fn: fn}
+}
+
+// LookupBuiltin шукає зареєстровану вбудовану функцію
+func LookupBuiltin(name string) (*Builtin, bool) {
+	builtinsMu.RLock()
+	defer builtinsMu.RUnlock()
+	builtin, ok := builtins[name]
+	return builtin, ok
+}
+
+func init() {
+	for name, fn := range defaultBuiltins {
+		RegisterBuiltin(name, fn)
+	}
+}
+
+var defaultBuiltins = map[string]BuiltinFunction{
+	"puts": func(args ...Object) Object {
+		for _, arg := range args {
+			fmt.Fprintln(BuiltinOutput, arg.Inspect())
+		}
+		return nil
+	},
+	"len": func(args ...Object) Object {
+		if len(args) != 1 {
+			return NewError("wrong number of arguments. got=%d, want=1", len(args))
+		}
+
+		switch arg := args[0].(type) {
+		case *String:
+			// як і for-in, рахуємо символи, а не байти
+			return &Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+		case *Array:
+			return &Integer{Value: int64(len(arg.Elements))}
+		case *Hash:
+			return &Integer{Value: int64(len(arg.Pairs))}
+		case *Range:
+			return &Integer{Value: arg.Len()}
+		default:
+			return NewError("argument to `len` not supported, got %s", args[0].Type())
+		}
+	},
+	"first": func(args ...Object) Object {
+		arr, err := arrayArgument("first", 1, args)
+		if err != nil {
+			return err
+		}
+		if len(arr.Elements) > 0 {
+			return arr.Elements[0]
+		}
+		return nil
+	},
+	"last": func(args ...Object) Object {
+		arr, err := arrayArgument("last", 1, args)
+		if err != nil {
+			return err
+		}
+		if length := len(arr.Elements); length > 0 {
+			return arr.Elements[length-1]
+		}
+		return nil
+	},
+	"rest": func(args ...Object) Object {
+		arr, err := arrayArgument("rest", 1, args)
+		if err != nil {
+			return err
+		}
+		length := len(arr.Elements)
+		if length == 0 {
+			return nil
+		}
+		// копіюємо, щоб не ділити масив під капотом з оригіналом
+		newElements := make([]Object, length-1)
+		copy(newElements, arr.Elements[1:])
+		return &Array{Elements: newElements}
+	},
+	"push": func(args ...Object) Object {
+		arr, err := arrayArgument("push", 2, args)
+		if err != nil {
+			return err
+		}
+		length := len(arr.Elements)
+		newElements := make([]Object, length+1)
+		copy(newElements, arr.Elements)
+		newElements[length] = args[1]
+		return &Array{Elements: newElements}
+	},
+	"keys": func(args ...Object) Object {
+		hash, err := hashArgument("keys", 1, args)
+		if err != nil {
+			return err
+		}
+		keys := []Object{}
+		for _, pair := range hash.OrderedPairs() {
+			keys = append(keys, pair.Key)
+		}
+		return &Array{Elements: keys}
+	},
+	"values": func(args ...Object) Object {
+		hash, err := hashArgument("values", 1, args)
+		if err != nil {
+			return err
+		}
+		values := []Object{}
+		for _, pair := range hash.OrderedPairs() {
+			values = append(values, pair.Value)
+		}
+		return &Array{Elements: values}
+	},
+	"delete": func(args ...Object) Object {
+		hash, err := hashArgument("delete", 2, args)
+		if err != nil {
+			return err
+		}
+		key, ok := args[1].(Hashable)
+		if !ok {
+			return NewError("unusable as hash key: %s", args[1].Type())
+		}
+		// як і push, повертає новий хеш, оригінал не змінюється
+		newHash := NewHash()
+		for _, pair := range hash.OrderedPairs() {
+			newHash.Set(pair.Key.(Hashable), pair.Value)
+		}
+		newHash.Delete(key)
+		return newHash
+	},
+	// range(end), range(start, end) або range(start, end, step)
+	"range": func(args ...Object) Object {
+		if len(args) < 1 || len(args) > 3 {
+			return NewError("wrong number of arguments. got=%d, want=1..3", len(args))
+		}
+
+		bounds := make([]int64, len(args))
+		for i, arg := range args {
+			integer, ok := arg.(*Integer)
+			if !ok {
+				return NewError("argument to `range` must be INTEGER, got %s", arg.Type())
+			}
+			bounds[i] = integer.Value
+		}
+
+		start, step := int64(0), int64(1)
+		end := bounds[0]
+		if len(bounds) > 1 {
+			start, end = bounds[0], bounds[1]
+		}
+		if len(bounds) > 2 {
+			step = bounds[2]
+		}
+		r, err := NewRange(start, end, step)
+		if err != nil {
+			return err
+		}
+		return r
+	},
+	// int відкидає дробову частину числа або розбирає десятковий рядок
+	"int": func(args ...Object) Object {
+		if len(args) != 1 {
+			return NewError("wrong number of arguments. got=%d, want=1", len(args))
+		}
+
+		switch arg := args[0].(type) {
+		case *Integer, *BigInteger:
+			return arg
+		case *Float:
+			// -2^63 точно представляється у float64, а 2^63 вже не влазить в int64
+			if math.IsNaN(arg.Value) || arg.Value < math.MinInt64 || arg.Value >= math.MaxInt64 {
+				return NewError("cannot convert %s to INTEGER", arg.Inspect())
+			}
+			return &Integer{Value: int64(arg.Value)}
+		case *String:
+			value, err := strconv.ParseInt(strings.TrimSpace(arg.Value), 10, 64)
+			if err != nil {
+				return NewError("could not parse %q as INTEGER", arg.Value)
+			}
+			return &Integer{Value: value}
+		default:
+			return NewError("argument to `int` not supported, got %s", args[0].Type())
+		}
+	},
+	"float": func(args ...Object) Object {
+		if len(args) != 1 {
+			return NewError("wrong number of arguments. got=%d, want=1", len(args))
+		}
+
+		switch arg := args[0].(type) {
+		case *Float:
+			return arg
+		case *Integer, *BigInteger:
+			value, _ := FloatValue(arg)
+			return &Float{Value: value}
+		case *String:
+			value, err := strconv.ParseFloat(strings.TrimSpace(arg.Value), 64)
+			if err != nil {
+				return NewError("could not parse %q as FLOAT", arg.Value)
+			}
+			return &Float{Value: value}
+		default:
+			return NewError("argument to `float` not supported, got %s", args[0].Type())
+		}
+	},
+}
+
+// arrayArgument перевіряє кількість аргументів і що перший з них - масив
+func arrayArgument(name string, want int, args []Object) (*Array, *Error) {
+	if len(args) != want {
+		return nil, NewError("wrong number of arguments. got=%d, want=%d", len(args), want)
+	}
+	arr, ok := args[0].(*Array)
+	if !ok {
+		return nil, NewError("argument to `%s` must be ARRAY, got %s", name, args[0].Type())
+	}
+	return arr, nil
+}
+
+// hashArgument перевіряє кількість аргументів і що перший з них - хеш
+func hashArgument(name string, want int, args []Object) (*Hash, *Error) {
+	if len(args) != want {
+		return nil, NewError("wrong number of arguments. got=%d, want=%d", len(args), want)
+	}
+	hash, ok := args[0].(*Hash)
+	if !ok {
+		return nil, NewError("argument to `%s` must be HASH, got %s", name, args[0].Type())
+	}
+	return hash, nil
+}
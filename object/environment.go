@@ -1,10 +1,18 @@
 package object
 
+import "sort"
+
 type Environment struct {
-	store map[string]Object
+	store map[string]Binding
 	outer *Environment
 }
 
+// Binding - значення імені і те, чи можна його змінювати
+type Binding struct {
+	Value    Object
+	Constant bool
+}
+
 //це зроблено для того аби кожна функція мала свій скоп, і не перезатирала скоп зовнішнішньої
 //let a = 10;
 // let b = 10;
@@ -18,26 +26,64 @@ type Environment struct {
 //  }
 //l(a,b) -> без внутрішнього скоупу викликався б як 1 та 10 бо всередині с ми б затерли зовнішній скоуп
 
-func NewEnclosingEnvironment() *Environment {
+func NewEnclosingEnvironment(outer *Environment) *Environment {
 	env := NewEnvironment()
-	env.outer = env
+	env.outer = outer
 	return env
 }
 
 func NewEnvironment() *Environment {
-	s := make(map[string]Object)
+	s := make(map[string]Binding)
 	return &Environment{store: s, outer: nil}
 }
 
 func (e *Environment) Get(name string) (Object, bool) {
-	obj, ok := e.store[name]
+	binding, ok := e.Lookup(name)
+	return binding.Value, ok
+}
+
+// Lookup повертає прив'язку імені разом з тим, чи це константа
+func (e *Environment) Lookup(name string) (Binding, bool) {
+	binding, ok := e.store[name]
 	if !ok && e.outer != nil {
-		obj, ok = e.outer.Get(name)
+		binding, ok = e.outer.Lookup(name)
 	}
-	return obj, ok
+	return binding, ok
 }
 
+// Set оголошує змінну в цьому скоупі
 func (e *Environment) Set(name string, val Object) Object {
-	e.store[name] = val
+	e.store[name] = Binding{Value: val}
+	return val
+}
+
+// SetConst оголошує константу в цьому скоупі
+func (e *Environment) SetConst(name string, val Object) Object {
+	e.store[name] = Binding{Value: val, Constant: true}
 	return val
 }
+
+// Assign змінює значення імені в тому скоупі, де його оголошено, обходячи outer.
+// для неоголошеного імені повертає *Error
+func (e *Environment) Assign(name string, val Object) Object {
+	for env := e; env != nil; env = env.outer {
+		if binding, ok := env.store[name]; ok {
+			if binding.Constant {
+				return NewError("cannot assign to constant: %s", name)
+			}
+			env.store[name] = Binding{Value: val}
+			return val
+		}
+	}
+	return NewError("assignment to undeclared identifier: %s", name)
+}
+
+// Names повертає відсортовані імена, оголошені саме в цьому скоупі (без outer)
+func (e *Environment) Names() []string {
+	names := make([]string, 0, len(e.store))
+	for name := range e.store {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,116 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// Range - цілі від Start до End (не включно) з кроком Step. Числа не зберігаються,
+// тому range(1000000000) не займає пам'яті
+type Range struct {
+	Start, End, Step int64
+}
+
+// NewRange перевіряє крок: нульовий крок дав би нескінченний цикл
+func NewRange(start, end, step int64) (*Range, *Error) {
+	if step == 0 {
+		return nil, NewError("range step must not be zero")
+	}
+	return &Range{Start: start, End: end, Step: step}, nil
+}
+
+func (r *Range) Type() ObjectType {
+	return RANGE_OBJ
+}
+
+func (r *Range) Inspect() string {
+	if r.Step == 1 {
+		return fmt.Sprintf("range(%d, %d)", r.Start, r.End)
+	}
+	return fmt.Sprintf("range(%d, %d, %d)", r.Start, r.End, r.Step)
+}
+
+// Len - кількість чисел у діапазоні. різниця рахується в uint64, щоб не переповнитись
+func (r *Range) Len() int64 {
+	var distance, step uint64
+	switch {
+	case r.Step > 0 && r.Start < r.End:
+		distance, step = uint64(r.End)-uint64(r.Start), uint64(r.Step)
+	case r.Step < 0 && r.Start > r.End:
+		distance, step = uint64(r.Start)-uint64(r.End), -uint64(r.Step)
+	default:
+		return 0
+	}
+	n := (distance-1)/step + 1
+	if n > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(n)
+}
+
+// Iterator видає елементи колекції по одному для циклу for
+type Iterator struct {
+	next func() (Object, bool)
+}
+
+func (it *Iterator) Type() ObjectType {
+	return ITERATOR_OBJ
+}
+
+func (it *Iterator) Inspect() string {
+	return "iterator"
+}
+
+// Next повертає наступний елемент, або false, якщо елементи закінчились
+func (it *Iterator) Next() (Object, bool) {
+	return it.next()
+}
+
+// NewIterator обходить масив по елементах, хеш по ключах у порядку вставки,
+// рядок по символах і діапазон по числах
+func NewIterator(obj Object) (*Iterator, *Error) {
+	switch obj := obj.(type) {
+	case *Array:
+		i := 0
+		return &Iterator{next: func() (Object, bool) {
+			if i >= len(obj.Elements) {
+				return nil, false
+			}
+			i++
+			return obj.Elements[i-1], true
+		}}, nil
+	case *Hash:
+		pairs := obj.OrderedPairs()
+		i := 0
+		return &Iterator{next: func() (Object, bool) {
+			if i >= len(pairs) {
+				return nil, false
+			}
+			i++
+			return pairs[i-1].Key, true
+		}}, nil
+	case *String:
+		rest := obj.Value
+		return &Iterator{next: func() (Object, bool) {
+			if rest == "" {
+				return nil, false
+			}
+			_, size := utf8.DecodeRuneInString(rest)
+			ch := rest[:size]
+			rest = rest[size:]
+			return &String{Value: ch}, true
+		}}, nil
+	case *Range:
+		i, n := int64(0), obj.Len()
+		return &Iterator{next: func() (Object, bool) {
+			if i >= n {
+				return nil, false
+			}
+			i++
+			return &Integer{Value: obj.Start + (i-1)*obj.Step}, true
+		}}, nil
+	default:
+		return nil, NewError("cannot iterate over %s", obj.Type())
+	}
+}
@@ -4,6 +4,11 @@ import (
 	"bytes"
 	"fmt"
 	"interpreter/ast"
+	"interpreter/code"
+	"interpreter/token"
+	"math"
+	"math/big"
+	"strconv"
 	"strings"
 )
 
@@ -16,21 +21,50 @@ type Object interface {
 
 const (
 	INTEGER_OBJ      = "INTEGER"
+	FLOAT_OBJ        = "FLOAT"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
+	BREAK_OBJ        = "BREAK"
+	CONTINUE_OBJ     = "CONTINUE"
 	ERROR_OBJ        = "ERROR"
 	FUNCTION_OBJ     = "FUNCTION"
+	STRING_OBJ       = "STRING"
+	ARRAY_OBJ        = "ARRAY"
+	BUILTIN_OBJ      = "BUILTIN"
+	HASH_OBJ         = "HASH"
+	RANGE_OBJ        = "RANGE"
+	ITERATOR_OBJ     = "ITERATOR"
+
+	COMPILED_FUNCTION_OBJ = "COMPILED_FUNCTION"
+	CELL_OBJ              = "CELL"
 )
 
 type Error struct {
 	Message string
+	// Pos - місце в коді, де виникла помилка. нульова, якщо невідома
+	Pos token.Position
+}
+
+func NewError(format string, a ...any) *Error {
+	return &Error{Message: fmt.Sprintf(format, a...)}
 }
 
 func (e *Error) Inspect() string {
+	if e.Pos.IsValid() {
+		return "ERROR: " + e.Pos.String() + ": " + e.Message
+	}
 	return "ERROR: " + e.Message
 }
 
+// Error дозволяє повертати *Error як звичайну помилку Go (так робить vm)
+func (e *Error) Error() string {
+	if e.Pos.IsValid() {
+		return e.Pos.String() + ": " + e.Message
+	}
+	return e.Message
+}
+
 func (e *Error) Type() ObjectType {
 	return ERROR_OBJ
 }
@@ -47,6 +81,67 @@ func (i *Integer) Type() ObjectType {
 	return INTEGER_OBJ
 }
 
+// BigInteger - ціле довільної точності. Для скриптів це той самий тип INTEGER:
+// значення, що влазять в int64, завжди зберігаються як *Integer, див. NewInteger
+type BigInteger struct {
+	Value *big.Int
+}
+
+// NewInteger повертає *Integer, якщо value влазить в int64, інакше *BigInteger
+func NewInteger(value *big.Int) Object {
+	if value.IsInt64() {
+		return &Integer{Value: value.Int64()}
+	}
+	return &BigInteger{Value: value}
+}
+
+func (i *BigInteger) Inspect() string {
+	return i.Value.String()
+}
+
+func (i *BigInteger) Type() ObjectType {
+	return INTEGER_OBJ
+}
+
+// велике ціле завжди поза межами int64, тож його ключ не збігається з ключем Integer
+func (i *BigInteger) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Text: i.Value.String()}
+}
+
+type Float struct {
+	Value float64
+}
+
+// Inspect завжди показує дробову частину або експоненту, щоб 2.0 не виглядало як ціле 2.
+// Експонента лише для дуже великих і дуже малих чисел, як у JavaScript: 1000000.0, але 1e+21
+func (f *Float) Inspect() string {
+	format := byte('f')
+	if abs := math.Abs(f.Value); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'g'
+	}
+	s := strconv.FormatFloat(f.Value, format, -1, 64)
+	if strings.ContainsAny(s, ".eIN") {
+		return s
+	}
+	return s + ".0"
+}
+
+func (f *Float) Type() ObjectType {
+	return FLOAT_OBJ
+}
+
+type String struct {
+	Value string
+}
+
+func (s *String) Inspect() string {
+	return s.Value
+}
+
+func (s *String) Type() ObjectType {
+	return STRING_OBJ
+}
+
 type Boolean struct {
 	Value bool
 }
@@ -82,6 +177,27 @@ func (rv *ReturnValue) Inspect() string {
 	return rv.Value.Inspect()
 }
 
+// Break і Continue, як і ReturnValue, піднімаються з блоку до найближчого циклу
+type Break struct{}
+
+func (b *Break) Type() ObjectType {
+	return BREAK_OBJ
+}
+
+func (b *Break) Inspect() string {
+	return "break"
+}
+
+type Continue struct{}
+
+func (c *Continue) Type() ObjectType {
+	return CONTINUE_OBJ
+}
+
+func (c *Continue) Inspect() string {
+	return "continue"
+}
+
 type Function struct {
 	Parameters []*ast.Identifier
 	Body       *ast.BlockStatement
@@ -110,3 +226,234 @@ func (f *Function) Inspect() string {
 	return out.String()
 
 }
+
+type Array struct {
+	Elements []Object
+}
+
+func (a *Array) Type() ObjectType {
+	return ARRAY_OBJ
+}
+
+func (a *Array) Inspect() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, el := range a.Elements {
+		elements = append(elements, el.Inspect())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+type BuiltinFunction func(args ...Object) Object
+
+// Caller викликає функцію скрипта в запуску, що викликав вбудовану функцію:
+// з тим самим контекстом, обмеженнями і лічильниками кроків та глибини
+type Caller func(fn Object, args []Object) Object
+
+type Builtin struct {
+	Name string
+	Fn   BuiltinFunction
+	// CallerFn, якщо задана, evaluator викликає замість Fn. так функція хоста
+	// викликає передані їй функції скрипта з обмеженнями запуску
+	CallerFn func(call Caller, args ...Object) Object
+}
+
+func (b *Builtin) Type() ObjectType {
+	return BUILTIN_OBJ
+}
+
+func (b *Builtin) Inspect() string {
+	return "builtin function " + b.Name
+}
+
+// HashKey - значення за яким об'єкт зберігається в хеші.
+// два різні об'єкти з однаковим значенням дають однаковий ключ, а різні значення -
+// завжди різні ключі: рядки зберігають сам текст, а не його хеш
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+	Text  string
+}
+
+type Hashable interface {
+	Object
+	HashKey() HashKey
+}
+
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+}
+
+func (b *Boolean) HashKey() HashKey {
+	var value uint64
+	if b.Value {
+		value = 1
+	}
+	return HashKey{Type: b.Type(), Value: value}
+}
+
+func (s *String) HashKey() HashKey {
+	return HashKey{Type: s.Type(), Text: s.Value}
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+// Hash пам'ятає порядок вставки ключів, тому Inspect, keys та values детерміновані
+type Hash struct {
+	Pairs map[HashKey]HashPair
+	order []HashKey
+}
+
+func NewHash() *Hash {
+	return &Hash{Pairs: make(map[HashKey]HashPair)}
+}
+
+func (h *Hash) Set(key Hashable, value Object) {
+	if h.Pairs == nil {
+		h.Pairs = make(map[HashKey]HashPair)
+	}
+	hashKey := key.HashKey()
+	if _, ok := h.Pairs[hashKey]; !ok {
+		h.order = append(h.order, hashKey)
+	}
+	h.Pairs[hashKey] = HashPair{Key: key, Value: value}
+}
+
+// SetIndex виконує left[index] = value для масиву чи хешу і повертає value або *Error.
+// від'ємний індекс масиву рахується з кінця, як при читанні
+func SetIndex(left, index, value Object) Object {
+	switch left := left.(type) {
+	case *Array:
+		if index.Type() != INTEGER_OBJ {
+			return NewError("index operator not supported: %s[%s]", left.Type(), index.Type())
+		}
+		length := int64(len(left.Elements))
+		integer, ok := index.(*Integer)
+		idx := int64(-1)
+		if ok {
+			idx = integer.Value
+			if idx < 0 {
+				idx += length
+			}
+		}
+		if idx < 0 || idx >= length {
+			return NewError("index out of range: %s (length %d)", index.Inspect(), length)
+		}
+		left.Elements[idx] = value
+		return value
+	case *Hash:
+		key, ok := index.(Hashable)
+		if !ok {
+			return NewError("unusable as hash key: %s", index.Type())
+		}
+		left.Set(key, value)
+		return value
+	default:
+		return NewError("index assignment not supported: %s[%s]", left.Type(), index.Type())
+	}
+}
+
+func (h *Hash) Get(key Hashable) (Object, bool) {
+	pair, ok := h.Pairs[key.HashKey()]
+	if !ok {
+		return nil, false
+	}
+	return pair.Value, true
+}
+
+func (h *Hash) Delete(key Hashable) {
+	hashKey := key.HashKey()
+	if _, ok := h.Pairs[hashKey]; !ok {
+		return
+	}
+	delete(h.Pairs, hashKey)
+	for i, k := range h.order {
+		if k == hashKey {
+			h.order = append(h.order[:i:i], h.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// OrderedPairs повертає пари в порядку вставки
+func (h *Hash) OrderedPairs() []HashPair {
+	pairs := make([]HashPair, 0, len(h.order))
+	for _, k := range h.order {
+		pairs = append(pairs, h.Pairs[k])
+	}
+	return pairs
+}
+
+func (h *Hash) Type() ObjectType {
+	return HASH_OBJ
+}
+
+func (h *Hash) Inspect() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range h.OrderedPairs() {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// CompiledFunction - тіло функції, скомпільоване в байткод
+type CompiledFunction struct {
+	Instructions  code.Instructions
+	NumLocals     int
+	NumParameters int
+	// Positions - місце в коді для зсуву інструкції, щоб помилки vm мали позицію
+	Positions map[int]token.Position
+}
+
+func (cf *CompiledFunction) Type() ObjectType {
+	return COMPILED_FUNCTION_OBJ
+}
+
+func (cf *CompiledFunction) Inspect() string {
+	return fmt.Sprintf("CompiledFunction[%p]", cf)
+}
+
+// Closure - скомпільована функція разом із захопленими вільними змінними.
+// для скрипта це така сама функція, як і Function в evaluator
+type Closure struct {
+	Fn   *CompiledFunction
+	Free []Object
+}
+
+func (c *Closure) Type() ObjectType {
+	return FUNCTION_OBJ
+}
+
+func (c *Closure) Inspect() string {
+	return fmt.Sprintf("Closure[%p]", c)
+}
+
+// Cell - змінна, яку захопило замикання vm. функція, що її оголосила, і замикання
+// читають і змінюють одну комірку, як спільне середовище в evaluator. скрипт її не бачить
+type Cell struct {
+	Value Object
+}
+
+func (c *Cell) Type() ObjectType {
+	return CELL_OBJ
+}
+
+func (c *Cell) Inspect() string {
+	return fmt.Sprintf("Cell[%p]", c)
+}
@@ -1,16 +1,31 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"interpreter/ast"
+	"interpreter/diagnostic"
 	"interpreter/lexer"
 	"interpreter/token"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 type Parser struct {
 	l      *lexer.Lexer
-	Errors []string
+	Errors []diagnostic.Diagnostic
+
+	// panicking - true після помилки і до синхронізації на межі інструкції.
+	// поки він виставлений, наступні (каскадні) помилки не додаються
+	panicking bool
+
+	// loopDepth - скільки циклів охоплюють поточну інструкцію в межах функції.
+	// break і continue поза циклом - помилка розбору
+	loopDepth int
+
+	// scopes - оголошені імена програми, функцій і блоків, що зараз розбираються
+	scopes []scope
 
 	currToken token.Token
 	peekToken token.Token
@@ -36,7 +51,8 @@ func (p *Parser) NextToken() {
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
 		l:      l,
-		Errors: []string{},
+		Errors: []diagnostic.Diagnostic{},
+		scopes: []scope{{names: map[string]declaration{}, function: true}},
 	}
 
 	p.NextToken()
@@ -48,25 +64,50 @@ func New(l *lexer.Lexer) *Parser {
 	//prefix
 	p.registerPrefixFn(token.IDENT, p.parseIdentifier)
 	p.registerPrefixFn(token.INT, p.parseIntegerLiteral)
+	p.registerPrefixFn(token.FLOAT, p.parseFloatLiteral)
+	p.registerPrefixFn(token.STRING, p.parseStringLiteral)
 	p.registerPrefixFn(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefixFn(token.BANG, p.parsePrefixExpression)
+	p.registerPrefixFn(token.TILDE, p.parsePrefixExpression)
 	p.registerPrefixFn(token.TRUE, p.parseBoolean)
 	p.registerPrefixFn(token.FALSE, p.parseBoolean)
 	p.registerPrefixFn(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefixFn(token.IF, p.parseIfExpression)
 	p.registerPrefixFn(token.FUNCTION, p.parseFunction)
+	p.registerPrefixFn(token.LBRACKET, p.parseArrayLiteral)
+	// блоки парсяться тільки явно через parseBlockStatement (if, fn),
+	// тому '{' на місці виразу - це завжди хеш
+	p.registerPrefixFn(token.LBRACE, p.parseHashLiteral)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	//infix
 	p.registerInfixFn(token.LPAREN, p.parseCallFunction)
+	p.registerInfixFn(token.LBRACKET, p.parseIndexExpression)
 	p.registerInfixFn(token.ASTERISK, p.parseInfixExpression)
 	p.registerInfixFn(token.SLASH, p.parseInfixExpression)
 	p.registerInfixFn(token.MINUS, p.parseInfixExpression)
 	p.registerInfixFn(token.PLUS, p.parseInfixExpression)
+	p.registerInfixFn(token.AMPERSAND, p.parseInfixExpression)
+	p.registerInfixFn(token.PIPE, p.parseInfixExpression)
+	p.registerInfixFn(token.CARET, p.parseInfixExpression)
+	p.registerInfixFn(token.SHIFT_LEFT, p.parseInfixExpression)
+	p.registerInfixFn(token.SHIFT_RIGHT, p.parseInfixExpression)
+	p.registerInfixFn(token.COALESCE, p.parseInfixExpression)
+	p.registerInfixFn(token.QUESTION, p.parseConditionalExpression)
+	p.registerInfixFn(token.QUESTION_DOT, p.parseOptionalChain)
 	p.registerInfixFn(token.EQ, p.parseInfixExpression)
 	p.registerInfixFn(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfixFn(token.LT, p.parseInfixExpression)
 	p.registerInfixFn(token.GT, p.parseInfixExpression)
+	p.registerInfixFn(token.LT_EQ, p.parseInfixExpression)
+	p.registerInfixFn(token.GT_EQ, p.parseInfixExpression)
+	p.registerInfixFn(token.PERCENT, p.parseInfixExpression)
+	p.registerInfixFn(token.AND, p.parseInfixExpression)
+	p.registerInfixFn(token.OR, p.parseInfixExpression)
+	for _, tokenType := range []token.TokenType{token.ASSIGN, token.PLUS_ASSIGN, token.MINUS_ASSIGN,
+		token.ASTERISK_ASSIGN, token.SLASH_ASSIGN} {
+		p.registerInfixFn(tokenType, p.parseAssignExpression)
+	}
 
 	return p
 }
@@ -74,6 +115,7 @@ func New(l *lexer.Lexer) *Parser {
 func (p *Parser) parseFunction() ast.Expression {
 	function := &ast.FunctionLiteral{
 		Token: p.currToken,
+		Doc:   p.currToken.Doc,
 	}
 
 	if !p.expectPeek(token.LPAREN) {
@@ -82,8 +124,19 @@ func (p *Parser) parseFunction() ast.Expression {
 	// p.NextToken()
 
 	function.Parameters = p.parseFunctionParameters()
+
+	p.enterScope()
+	defer p.leaveScope()
+	for _, param := range function.Parameters {
+		p.declare(param, false)
+	}
+
 	if p.expectPeek(token.LBRACE) {
+		// break у тілі функції не може вийти з циклу, що її оточує
+		loopDepth := p.loopDepth
+		p.loopDepth = 0
 		function.Body = *p.parseBlockStatement()
+		p.loopDepth = loopDepth
 	}
 
 	return function
@@ -98,14 +151,18 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 		return nil
 	}
 
-	p.NextToken()
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
 
 	ident := p.parseIdentifier().(*ast.Identifier)
 	identifiers = append(identifiers, ident)
 
 	for p.peekTokenIs(token.COMMA) {
 		p.NextToken()
-		p.NextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
 		ident := p.parseIdentifier().(*ast.Identifier)
 		identifiers = append(identifiers, ident)
 	}
@@ -157,13 +214,23 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block.Statements = []ast.Statement{}
 	p.NextToken()
 
+	p.enterBlock()
+	defer p.leaveScope()
+
 	for !p.currTokenIs(token.RBRACE) && !p.currTokenIs(token.EOF) {
-		stmt := p.parseStatement()
+		stmt, closed := p.parseStatementRecovering()
 		if stmt != nil {
 			block.Statements = append(block.Statements, stmt)
 		}
+		if closed {
+			break
+		}
 		p.NextToken()
 	}
+
+	if p.currTokenIs(token.EOF) {
+		p.addError(p.currToken, "input ended unexpectedly", "expected next token to be: %s but was: %s", token.RBRACE, token.EOF)
+	}
 	return block
 }
 
@@ -213,37 +280,158 @@ func (p *Parser) parseCallFunction(function ast.Expression) ast.Expression {
 		Function: function,
 	}
 
-	args := []ast.Expression{}
-	if p.peekTokenIs(token.RPAREN) {
-		callExpr.Arguments = args
+	args := p.parseExpressionList(token.RPAREN)
+	if args == nil {
+		return nil
+	}
+	callExpr.Arguments = args
+
+	return callExpr
+
+}
+
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{
+		Token: p.currToken,
+	}
+
+	elements := p.parseExpressionList(token.RBRACKET)
+	if elements == nil {
+		return nil
+	}
+	array.Elements = elements
+
+	return array
+}
+
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{
+		Token: p.currToken,
+		Pairs: []ast.HashPair{},
+	}
+
+	for !p.peekTokenIs(token.RBRACE) {
 		p.NextToken()
-		return callExpr
+		key := p.parseExpression(LOWEST)
+
+		if !p.expectPeek(token.COLON) {
+			return nil
+		}
+
+		p.NextToken()
+		value := p.parseExpression(LOWEST)
+
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return hash
+}
+
+// parseConditionalExpression права асоціативність: a ? b : c ? d : e це a ? b : (c ? d : e)
+func (p *Parser) parseConditionalExpression(condition ast.Expression) ast.Expression {
+	exp := &ast.ConditionalExpression{
+		Token:     p.currToken,
+		Condition: condition,
 	}
+
 	p.NextToken()
-	args = append(args, p.parseExpression(LOWEST))
+	exp.Consequence = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.COLON) {
+		return nil
+	}
+
+	p.NextToken()
+	exp.Alternative = p.parseExpression(TERNARY - 1)
+
+	return exp
+}
+
+// parseOptionalChain розбирає a?.[i] і f?.(x). ?. діє тільки на одну операцію:
+// у a?.[0][1] другий індекс застосовується і до NULL, тому пишуть a?.[0]?.[1]
+func (p *Parser) parseOptionalChain(left ast.Expression) ast.Expression {
+	switch p.peekToken.Type {
+	case token.LBRACKET:
+		p.NextToken()
+		if exp, ok := p.parseIndexExpression(left).(*ast.IndexExpression); ok {
+			exp.Optional = true
+			return exp
+		}
+	case token.LPAREN:
+		p.NextToken()
+		if exp, ok := p.parseCallFunction(left).(*ast.CallExpression); ok {
+			exp.Optional = true
+			return exp
+		}
+	default:
+		p.addError(p.peekToken, "?. is followed by an index a?.[i] or a call f?.(x)",
+			"expected [ or ( after ?., got %s", p.peekToken.Type)
+	}
+	return nil
+}
+
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	exp := &ast.IndexExpression{
+		Token: p.currToken,
+		Left:  left,
+	}
+
+	p.NextToken()
+	exp.Index = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return exp
+}
+
+// parseExpressionList парсить вирази через кому до токена end (аргументи виклику, елементи масиву).
+// повертає nil якщо список не закритий
+func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	list := []ast.Expression{}
+
+	if p.peekTokenIs(end) {
+		p.NextToken()
+		return list
+	}
+
+	p.NextToken()
+	list = append(list, p.parseExpression(LOWEST))
 
 	for p.peekTokenIs(token.COMMA) {
 		p.NextToken()
 		p.NextToken()
 		expr := p.parseExpression(LOWEST)
 		if expr != nil {
-			args = append(args, expr)
+			list = append(list, expr)
 		}
 	}
 
-	if !p.expectPeek(token.RPAREN) {
+	if !p.expectPeek(end) {
 		return nil
 	}
 
-	callExpr.Arguments = args
-
-	return callExpr
-
+	return list
 }
 
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("expected next token to be: %s but was: %s", t, p.peekToken.Type)
-	p.Errors = append(p.Errors, msg)
+	hint := ""
+	switch {
+	case p.peekTokenIs(token.EOF):
+		hint = "input ended unexpectedly"
+	case t == token.SEMICOLON:
+		hint = "statements must end with ';'"
+	}
+	p.addError(p.peekToken, hint, "expected next token to be: %s but was: %s", t, p.peekToken.Type)
 }
 
 func (p *Parser) ParseProgram() *ast.Program {
@@ -251,7 +439,8 @@ func (p *Parser) ParseProgram() *ast.Program {
 	program.Statements = []ast.Statement{}
 
 	for p.currToken.Type != token.EOF {
-		stmt := p.parseStatement()
+		// на верхньому рівні зайва '}' просто пропускається
+		stmt, _ := p.parseStatementRecovering()
 		if stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
@@ -259,29 +448,169 @@ func (p *Parser) ParseProgram() *ast.Program {
 		p.NextToken()
 	}
 
+	// лексер видає EOF і на помилці читання - тоді програма обрізана, і це помилка розбору
+	if err := p.l.Err(); err != nil {
+		p.Errors = append(p.Errors, diagnostic.Diagnostic{
+			Severity: diagnostic.Error,
+			Span:     diagnostic.TokenSpan(p.currToken),
+			Message:  fmt.Sprintf("cannot read input: %s", err),
+		})
+	}
+
 	return &program
 }
 
+// parseStatementRecovering парсить інструкцію, а якщо в ній була помилка -
+// пропускає токени до межі інструкції і повертає ast.BadStatement замість неї.
+// closed = true, якщо помилковим токеном була '}', яка має закрити поточний блок
+func (p *Parser) parseStatementRecovering() (stmt ast.Statement, closed bool) {
+	start := p.currToken
+	stmt = p.parseStatement()
+	if !p.panicking {
+		return stmt, false
+	}
+
+	p.synchronize()
+	bad := &ast.BadStatement{Token: start, End: p.currToken.End}
+	return bad, p.currTokenIs(token.RBRACE)
+}
+
+// synchronize пропускає токени до ';' (включно), або до '}', let, return чи кінця вводу (не включно).
+// вкладені {...} пропускаються цілком. після неї currToken - останній токен зламаної інструкції
+func (p *Parser) synchronize() {
+	p.panicking = false
+	depth := 0
+
+	for !p.currTokenIs(token.EOF) {
+		switch p.currToken.Type {
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			if depth == 0 {
+				return
+			}
+			depth--
+		case token.SEMICOLON:
+			if depth == 0 {
+				return
+			}
+		}
+
+		if depth == 0 && (p.peekTokenIs(token.LET) || p.peekTokenIs(token.CONST) || p.peekTokenIs(token.RETURN) ||
+			p.peekTokenIs(token.WHILE) || p.peekTokenIs(token.FOR) ||
+			p.peekTokenIs(token.RBRACE) || p.peekTokenIs(token.EOF)) {
+			return
+		}
+		p.NextToken()
+	}
+}
+
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.currToken.Type {
-	case token.LET:
+	case token.SEMICOLON:
+		// порожня інструкція
+		return nil
+	case token.LET, token.CONST:
 		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.WHILE:
+		return p.parseWhileStatement()
+	case token.FOR:
+		return p.parseForStatement()
+	case token.BREAK, token.CONTINUE:
+		return p.parseLoopControlStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
 }
 
+func (p *Parser) parseWhileStatement() ast.Statement {
+	stmt := &ast.WhileStatement{Token: p.currToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	stmt.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	stmt.Body = p.parseLoopBody()
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.NextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseForStatement() ast.Statement {
+	stmt := &ast.ForStatement{Token: p.currToken}
+
+	if !p.expectPeek(token.LPAREN) || !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Variable = &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
+	p.declareLoopVariable(stmt.Variable)
+
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+	p.NextToken()
+	stmt.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) || !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	stmt.Body = p.parseLoopBody()
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.NextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	p.loopDepth++
+	defer func() { p.loopDepth-- }()
+	return p.parseBlockStatement()
+}
+
+// parseLoopControlStatement розбирає break або continue. ';' після них необов'язкова
+func (p *Parser) parseLoopControlStatement() ast.Statement {
+	tok := p.currToken
+	if p.loopDepth == 0 {
+		p.addError(tok, "", "%s outside of a loop", tok.Literal)
+		return nil
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.NextToken()
+	}
+	if tok.Type == token.BREAK {
+		return &ast.BreakStatement{Token: tok}
+	}
+	return &ast.ContinueStatement{Token: tok}
+}
+
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{
 		Token: p.currToken,
 	}
 
+	if offset, width, msg := checkIntegerLiteral(lit.Token.Literal); msg != "" {
+		p.addError(subToken(lit.Token, offset, width), "", "%s", msg)
+		return nil
+	}
+
 	int, err := strconv.ParseInt(lit.TokenLiteral(), 0, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		p.addError(p.currToken, "integers are 64-bit: from -9223372036854775808 to 9223372036854775807",
+			"integer literal out of range: %s", p.currToken.Literal)
+		return nil
+	}
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.currToken.Literal)
-		p.Errors = append(p.Errors, msg)
+		p.addError(p.currToken, "", "could not parse %q as integer", p.currToken.Literal)
 		return nil
 	}
 
@@ -289,6 +618,97 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	return lit
 }
 
+// checkIntegerLiteral перевіряє цифри літерала в його основі (0x, 0o, 0b або десяткова)
+// і що '_' стоїть тільки між цифрами або після префікса. повертає повідомлення про першу
+// помилку і байтовий зсув та довжину символу, в якому вона, або порожнє повідомлення
+func checkIntegerLiteral(lit string) (offset, width int, msg string) {
+	base, name, start := 10, "decimal", 0
+	if len(lit) > 1 && lit[0] == '0' {
+		switch lit[1] {
+		case 'x', 'X':
+			base, name, start = 16, "hexadecimal", 2
+		case 'o', 'O':
+			base, name, start = 8, "octal", 2
+		case 'b', 'B':
+			base, name, start = 2, "binary", 2
+		}
+	}
+
+	digits := 0
+	underscore := false
+	for i, ch := range lit[start:] {
+		width := utf8.RuneLen(ch)
+		if ch == '_' {
+			if underscore {
+				return start + i, width, "'_' must separate successive digits"
+			}
+			underscore = true
+			continue
+		}
+		if digitValue(ch) >= base {
+			return start + i, width, fmt.Sprintf("invalid digit %q in %s literal", ch, name)
+		}
+		digits++
+		underscore = false
+	}
+
+	if digits == 0 {
+		return 0, len(lit), fmt.Sprintf("%s literal has no digits", name)
+	}
+	if underscore {
+		return len(lit) - 1, 1, "'_' must separate successive digits"
+	}
+	return 0, 0, ""
+}
+
+// digitValue - значення цифри в основі до 16; для інших символів більше за будь-яку основу
+func digitValue(ch rune) int {
+	switch {
+	case '0' <= ch && ch <= '9':
+		return int(ch - '0')
+	case 'a' <= ch && ch <= 'f':
+		return int(ch-'a') + 10
+	case 'A' <= ch && ch <= 'F':
+		return int(ch-'A') + 10
+	}
+	return 16
+}
+
+// subToken - частина токена tok від байта offset довжиною width байтів, щоб помилка
+// підкреслювала саме її. токен має бути в одному рядку
+func subToken(tok token.Token, offset, width int) token.Token {
+	sub := tok
+	sub.Literal = tok.Literal[offset : offset+width]
+	sub.Pos.Offset += offset
+	sub.Pos.Column += utf8.RuneCountInString(tok.Literal[:offset])
+	sub.End = sub.Pos
+	sub.End.Offset += width
+	sub.End.Column += utf8.RuneCountInString(sub.Literal)
+	return sub
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{
+		Token: p.currToken,
+	}
+
+	value, err := strconv.ParseFloat(lit.TokenLiteral(), 64)
+	if err != nil {
+		p.addError(p.currToken, "", "could not parse %q as float", p.currToken.Literal)
+		return nil
+	}
+
+	lit.Value = value
+	return lit
+}
+
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{
+		Token: p.currToken,
+		Value: p.currToken.Literal,
+	}
+}
+
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	stmt := &ast.ExpressionStatement{
 		Token: p.currToken,
@@ -307,7 +727,7 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 	prefix := p.prefixParseFns[p.currToken.Type]
 	if prefix == nil {
 		p.noPrefixParseFnError(p.currToken.Type)
-		return nil
+		return &ast.BadExpression{Token: p.currToken}
 	}
 	leftExpr := prefix()
 
@@ -323,8 +743,39 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 }
 
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function found for token type %s found", t)
-	p.Errors = append(p.Errors, msg)
+	if t == token.ILLEGAL && !utf8.ValidString(p.currToken.Literal) {
+		p.addError(p.currToken, "the source must be UTF-8 encoded", "invalid UTF-8 encoding: %q", p.currToken.Literal)
+		return
+	}
+
+	hint := ""
+	if t == token.ILLEGAL {
+		hint = "invalid character or malformed string literal"
+		if strings.HasPrefix(p.currToken.Literal, "/*") {
+			hint = "block comment is not closed with */"
+		}
+	}
+	p.addError(p.currToken, hint, "no prefix parse function found for token type %s found", t)
+}
+
+// addError додає помилку, що підкреслює токен tok. hint може бути порожнім.
+// помилки, що йдуть каскадом за першою в тій самій інструкції, або з тією ж позицією - відкидаються
+func (p *Parser) addError(tok token.Token, hint string, format string, a ...any) {
+	if p.panicking {
+		return
+	}
+	p.panicking = true
+
+	if n := len(p.Errors); n > 0 && p.Errors[n-1].Span.Start == tok.Pos {
+		return
+	}
+
+	p.Errors = append(p.Errors, diagnostic.Diagnostic{
+		Severity: diagnostic.Error,
+		Span:     diagnostic.TokenSpan(tok),
+		Message:  fmt.Sprintf(format, a...),
+		Hint:     hint,
+	})
 }
 
 func (p *Parser) parseReturnStatement() ast.Statement {
@@ -343,7 +794,7 @@ func (p *Parser) parseReturnStatement() ast.Statement {
 }
 
 func (p *Parser) parseLetStatement() ast.Statement {
-	stmt := &ast.LetStatement{Token: p.currToken}
+	stmt := &ast.LetStatement{Token: p.currToken, Doc: p.currToken.Doc}
 
 	if !p.expectPeek(token.IDENT) {
 		return nil
@@ -354,6 +805,8 @@ func (p *Parser) parseLetStatement() ast.Statement {
 		Value: p.currToken.Literal,
 	}
 	stmt.Name = ident
+	p.declare(ident, stmt.IsConst())
+
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
 	}
@@ -364,6 +817,11 @@ func (p *Parser) parseLetStatement() ast.Statement {
 		return nil
 	}
 
+	// документація "/// ...\nlet add = fn..." описує саму функцію
+	if fn, ok := stmt.Value.(*ast.FunctionLiteral); ok && fn.Doc == "" {
+		fn.Doc = stmt.Doc
+	}
+
 	return stmt
 }
 
@@ -371,7 +829,13 @@ func (p *Parser) currTokenIs(tokenType token.TokenType) bool {
 	return p.currToken.Type == tokenType
 }
 
+// expectPeek переходить на наступний токен, якщо він очікуваного типу.
+// після помилки в інструкції завжди повертає false, щоб парсер не з'їв токен,
+// на якому треба синхронізуватись
 func (p *Parser) expectPeek(expected token.TokenType) bool {
+	if p.panicking {
+		return false
+	}
 	if p.peekTokenIs(expected) {
 		p.NextToken()
 		return true
@@ -393,24 +857,53 @@ type (
 const (
 	_ int = iota
 	LOWEST
+	ASSIGNMENT  // = or +=
+	TERNARY     // ? :
+	COALESCE    // ??
+	LOGICAL_OR  // ||
+	LOGICAL_AND // &&
+	BIT_OR      // |
+	BIT_XOR     // ^
+	BIT_AND     // &
 	EQUALS      // ==
 	LESSGREATER // > or <
+	SHIFT       // << or >>
 	SUM         // +
 	PRODUCT     // *
-	PREFIX      // -X or !X
+	PREFIX      // -X, !X or ~X
 	CALL        // myFunction(X)
+	INDEX       // array[index]
 )
 
 var precedences = map[token.TokenType]int{
-	token.EQ:       EQUALS,
-	token.NOT_EQ:   EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.SLASH:    PRODUCT,
-	token.ASTERISK: PRODUCT,
-	token.LPAREN:   CALL,
+	token.ASSIGN:          ASSIGNMENT,
+	token.PLUS_ASSIGN:     ASSIGNMENT,
+	token.MINUS_ASSIGN:    ASSIGNMENT,
+	token.ASTERISK_ASSIGN: ASSIGNMENT,
+	token.SLASH_ASSIGN:    ASSIGNMENT,
+	token.QUESTION:        TERNARY,
+	token.COALESCE:        COALESCE,
+	token.EQ:              EQUALS,
+	token.NOT_EQ:          EQUALS,
+	token.OR:              LOGICAL_OR,
+	token.AND:             LOGICAL_AND,
+	token.LT:              LESSGREATER,
+	token.GT:              LESSGREATER,
+	token.LT_EQ:           LESSGREATER,
+	token.GT_EQ:           LESSGREATER,
+	token.PIPE:            BIT_OR,
+	token.CARET:           BIT_XOR,
+	token.AMPERSAND:       BIT_AND,
+	token.SHIFT_LEFT:      SHIFT,
+	token.SHIFT_RIGHT:     SHIFT,
+	token.PLUS:            SUM,
+	token.MINUS:           SUM,
+	token.SLASH:           PRODUCT,
+	token.ASTERISK:        PRODUCT,
+	token.PERCENT:         PRODUCT,
+	token.LPAREN:          CALL,
+	token.LBRACKET:        INDEX,
+	token.QUESTION_DOT:    INDEX,
 }
 
 func (p *Parser) peekPrecedence() int {
@@ -442,3 +935,32 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 
 	return exp
 }
+
+// parseAssignExpression права асоціативність: a = b = 1 це a = (b = 1)
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	exp := &ast.AssignExpression{
+		Token:    p.currToken,
+		Operator: p.currToken.Literal,
+		Target:   target,
+	}
+
+	switch target := target.(type) {
+	case *ast.Identifier:
+		p.checkAssignment(target)
+	case *ast.IndexExpression:
+		if target.Optional {
+			p.addError(p.currToken, "?. may be NULL, use a plain index to assign",
+				"invalid assignment target: %s", target.String())
+			return &ast.BadExpression{Token: p.currToken}
+		}
+	default:
+		p.addError(p.currToken, "only a name or an index expression can be assigned to",
+			"invalid assignment target: %s", target.String())
+		return &ast.BadExpression{Token: p.currToken}
+	}
+
+	p.NextToken()
+	exp.Value = p.parseExpression(ASSIGNMENT - 1)
+
+	return exp
+}
@@ -1,10 +1,14 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"interpreter/ast"
 	"interpreter/lexer"
+	"io"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestLetStatement(t *testing.T) {
@@ -76,7 +80,7 @@ func checkParserErrors(t *testing.T, p *Parser) {
 
 	t.Errorf("parser has %d errors", len(p.Errors))
 	for _, value := range p.Errors {
-		t.Errorf("parser error: %q", value)
+		t.Errorf("parser error: %q", value.Error())
 	}
 	t.FailNow()
 }
@@ -176,6 +180,100 @@ func TestIntegerLiterals(t *testing.T) {
 	}
 }
 
+func TestIntegerLiteralForms(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"0xFF", 255},
+		{"0Xff", 255},
+		{"0o755", 493},
+		{"0b1010", 10},
+		{"1_000_000", 1000000},
+		{"0x_7FFF_FFFF_FFFF_FFFF", 9223372036854775807},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		lit, ok := stmt.Expression.(*ast.IntegerLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.IntegerLiteral. got=%T", stmt.Expression)
+		}
+		if lit.Value != tt.expected {
+			t.Errorf("input %q: literal.Value not %d. got=%d", tt.input, tt.expected, lit.Value)
+		}
+		if lit.String() != tt.input {
+			t.Errorf("literal.String not %s. got=%s", tt.input, lit.String())
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 0b102;", "1:13: invalid digit '2' in binary literal"},
+		{"0o78", "1:4: invalid digit '8' in octal literal"},
+		{"0xFG", "1:4: invalid digit 'G' in hexadecimal literal"},
+		{"0x;", "1:1: hexadecimal literal has no digits"},
+		{"1__000", "1:3: '_' must separate successive digits"},
+		{"1_", "1:2: '_' must separate successive digits"},
+		{"9223372036854775808", "1:1: integer literal out of range: 9223372036854775808"},
+		{"0x1_0000_0000_0000_0000", "1:1: integer literal out of range: 0x1_0000_0000_0000_0000"},
+	}
+
+	for _, tt := range errorTests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		if len(p.Errors) == 0 {
+			t.Errorf("input %q: expected error %q, got none", tt.input, tt.expected)
+			continue
+		}
+		if got := p.Errors[0].Error(); got != tt.expected {
+			t.Errorf("input %q: wrong error. expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestFloatLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14", 3.14},
+		{".5", 0.5},
+		{"1e-9", 1e-9},
+		{"2.5E+3", 2500},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		lit, ok := stmt.Expression.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+		}
+		if lit.Value != tt.expected {
+			t.Errorf("literal.Value not %g. got=%g", tt.expected, lit.Value)
+		}
+		if lit.String() != tt.input {
+			t.Errorf("literal.String not %s. got=%s", tt.input, lit.String())
+		}
+	}
+
+	p := New(lexer.New("1e400"))
+	p.ParseProgram()
+	if len(p.Errors) != 1 || p.Errors[0].Message != `could not parse "1e400" as float` {
+		t.Errorf("expected out of range error, got %v", p.Errors)
+	}
+}
+
 func TestParsingPrefixExpressions(t *testing.T) {
 	testCases := []struct {
 		input    string
@@ -184,6 +282,7 @@ func TestParsingPrefixExpressions(t *testing.T) {
 	}{
 		{"!5", "!", 5},
 		{"-15", "-", 15},
+		{"~15", "~", 15},
 		{"!true;", "!", true},
 		{"!false;", "!", false},
 	}
@@ -296,6 +395,70 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"-a * b",
 			"((-a) * b)",
 		},
+		{
+			"a + b % c",
+			"(a + (b % c))",
+		},
+		{
+			"a | b ^ c & d",
+			"(a | (b ^ (c & d)))",
+		},
+		{
+			"a & b == c",
+			"(a & (b == c))",
+		},
+		{
+			"a && b | c",
+			"(a && (b | c))",
+		},
+		{
+			"1 << 2 + 3 < 4 >> 1",
+			"((1 << (2 + 3)) < (4 >> 1))",
+		},
+		{
+			"~a & -b",
+			"((~a) & (-b))",
+		},
+		{
+			"a ? b : c ? d : e",
+			"(a ? b : (c ? d : e))",
+		},
+		{
+			"x = a || b ? 1 : 2",
+			"(x = ((a || b) ? 1 : 2))",
+		},
+		{
+			"a ?? b || c ? d ?? e : f",
+			"((a ?? (b || c)) ? (d ?? e) : f)",
+		},
+		{
+			"c ? .5 : 1",
+			"(c ? .5 : 1)",
+		},
+		{
+			"-a?.[0]?.(1, 2)",
+			"(-(a?.[0])?.(1, 2))",
+		},
+		{
+			"add(a ? 1 : 2, b ?? 3)",
+			"add((a ? 1 : 2), (b ?? 3))",
+		},
+		{
+			"a <= b == b >= a",
+			"((a <= b) == (b >= a))",
+		},
+		{
+			"a || b && c",
+			"(a || (b && c))",
+		},
+		{
+			"a == b && c != d || !e",
+			"(((a == b) && (c != d)) || (!e))",
+		},
+		{
+			"a && b && c",
+			"((a && b) && c)",
+		},
 		{
 			"!-a",
 			"(!(-a))",
@@ -384,6 +547,26 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a + b + c * d / f + g)",
 			"add((((a + b) + ((c * d) / f)) + g))",
 		},
+		{
+			"a = b = c + 1",
+			"(a = (b = (c + 1)))",
+		},
+		{
+			"a[i] += b || c",
+			"((a[i]) += (b || c))",
+		},
+		{
+			"x -= f(y) * 2",
+			"(x -= (f(y) * 2))",
+		},
+		{
+			"a * [1, 2, 3, 4][b * c] * d",
+			"((a * ([1, 2, 3, 4][(b * c)])) * d)",
+		},
+		{
+			"add(a * b[2], b[1], 2 * [1, 2][1])",
+			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
+		},
 	}
 	for _, tt := range tests {
 		l := lexer.New(tt.input)
@@ -806,3 +989,455 @@ func TestReturnStatements(t *testing.T) {
 		}
 	}
 }
+
+func TestStringLiteralExpression(t *testing.T) {
+	input := `"hello world";`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.StringLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.StringLiteral. got=%T", stmt.Expression)
+	}
+
+	if literal.Value != "hello world" {
+		t.Errorf("literal.Value not %q. got=%q", "hello world", literal.Value)
+	}
+}
+
+func TestParsingArrayLiterals(t *testing.T) {
+	input := "[1, 2 * 2, 3 + 3]"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	array, ok := stmt.Expression.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("exp not ast.ArrayLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(array.Elements) != 3 {
+		t.Fatalf("len(array.Elements) not 3. got=%d", len(array.Elements))
+	}
+
+	testIntegerLiteral(t, array.Elements[0], 1)
+	testInfixExpression(t, array.Elements[1], 2, "*", 2)
+	testInfixExpression(t, array.Elements[2], 3, "+", 3)
+}
+
+func TestParsingEmptyArrayLiterals(t *testing.T) {
+	input := "[]"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	array, ok := stmt.Expression.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("exp not ast.ArrayLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(array.Elements) != 0 {
+		t.Errorf("len(array.Elements) not 0. got=%d", len(array.Elements))
+	}
+}
+
+func TestParsingIndexExpressions(t *testing.T) {
+	input := "myArray[1 + 1]"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	indexExp, ok := stmt.Expression.(*ast.IndexExpression)
+	if !ok {
+		t.Fatalf("exp not *ast.IndexExpression. got=%T", stmt.Expression)
+	}
+
+	if !testIdentifier(t, indexExp.Left, "myArray") {
+		return
+	}
+
+	if !testInfixExpression(t, indexExp.Index, 1, "+", 1) {
+		return
+	}
+}
+
+func TestParsingHashLiterals(t *testing.T) {
+	input := `{"one": 1, "two": 2, "three": 3}`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+	}
+
+	expected := []struct {
+		key   string
+		value int64
+	}{
+		{"one", 1},
+		{"two", 2},
+		{"three", 3},
+	}
+
+	if len(hash.Pairs) != len(expected) {
+		t.Fatalf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
+	}
+
+	for i, pair := range hash.Pairs {
+		literal, ok := pair.Key.(*ast.StringLiteral)
+		if !ok {
+			t.Errorf("key is not ast.StringLiteral. got=%T", pair.Key)
+			continue
+		}
+		if literal.Value != expected[i].key {
+			t.Errorf("key is not %q. got=%q", expected[i].key, literal.Value)
+		}
+		testIntegerLiteral(t, pair.Value, expected[i].value)
+	}
+}
+
+func TestParsingEmptyHashLiteral(t *testing.T) {
+	input := "{}"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(hash.Pairs) != 0 {
+		t.Errorf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
+	}
+}
+
+func TestParsingHashLiteralsWithExpressions(t *testing.T) {
+	input := `let h = {"one": 0 + 1, true: 10 - 8, 3: 15 / 5}; if (true) { h }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	expected := `let h = {"one": (0 + 1), true: (10 - 8), 3: (15 / 5)};if trueh`
+	if program.String() != expected {
+		t.Errorf("expected=%q, got=%q", expected, program.String())
+	}
+}
+
+func TestLoopStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"while (x < 10) { x }", "while (x < 10) {x}"},
+		{"while (true) { break; continue }", "while true {break;continue;}"},
+		{"for (x in [1, 2]) { puts(x); }", "for (x in [1, 2]) {puts(x)}"},
+		{"for (i in range(3)) { for (j in xs) { break; } };", "for (i in range(3)) {for (j in xs) {break;}}"},
+		{"while (a) { let f = fn() { while (b) { continue; } }; }", "while a {let f = fn() while b {continue;};}"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("input %q: program.Statements does not contain 1 statement. got=%d", tt.input, len(program.Statements))
+		}
+		if program.String() != tt.expected {
+			t.Errorf("input %q: expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+func TestLoopControlOutsideLoop(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"break;", "1:1: break outside of a loop"},
+		{"if (x) { continue }", "1:10: continue outside of a loop"},
+		{"while (x) { let f = fn() { break; }; }", "1:28: break outside of a loop"},
+		{"for (1 in xs) { }", "1:6: expected next token to be: IDENT but was: INT"},
+		{"for (x of xs) { }", "1:8: expected next token to be: in but was: IDENT"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		if len(p.Errors) == 0 {
+			t.Errorf("input %q: expected parser errors, got none", tt.input)
+			continue
+		}
+		if p.Errors[0].Error() != tt.expected {
+			t.Errorf("input %q: wrong error. expected=%q, got=%q", tt.input, tt.expected, p.Errors[0].Error())
+		}
+	}
+}
+
+func TestConstStatements(t *testing.T) {
+	p := New(lexer.New("const max = 10; let min = 0;"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if program.String() != "const max = 10;let min = 0;" {
+		t.Errorf("wrong program. got=%q", program.String())
+	}
+	if !program.Statements[0].(*ast.LetStatement).IsConst() || program.Statements[1].(*ast.LetStatement).IsConst() {
+		t.Errorf("IsConst is wrong for %q", program.String())
+	}
+}
+
+func TestDeclarationErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		hint     string
+	}{
+		{"let x = 1; let x = 2;", "1:16: identifier already declared: x", "declared at 1:5; use assignment to change its value"},
+		{"const x = 1; let x = 2;", "1:18: identifier already declared: x", "declared at 1:7"},
+		{"let x = 1; if (x) { let x = 2; }", "1:25: identifier already declared: x", "declared at 1:5; use assignment to change its value"},
+		{"let f = fn(a, a) { a };", "1:15: identifier already declared: a", "declared at 1:12; use assignment to change its value"},
+		{"const x = 1; x = 2;", "1:14: cannot assign to constant: x", "declared at 1:7"},
+		{"const x = 1; let f = fn() { x += 1; };", "1:29: cannot assign to constant: x", "declared at 1:7"},
+		{"const x = [1]; for (x in [2]) { }", "1:21: cannot assign to constant: x", "declared at 1:7"},
+		{"if (true) { let x = 1; if (x) { let x = 2; } }", "1:37: identifier already declared: x", "declared at 1:17; use assignment to change its value"},
+		{"if (true) { let x = 1; let x = 2; }", "1:28: identifier already declared: x", "declared at 1:17; use assignment to change its value"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		if len(p.Errors) != 1 {
+			t.Errorf("input %q: expected 1 error, got %v", tt.input, p.Errors)
+			continue
+		}
+		if p.Errors[0].Error() != tt.expected || p.Errors[0].Hint != tt.hint {
+			t.Errorf("input %q: wrong error. expected=%q (%q), got=%q (%q)",
+				tt.input, tt.expected, tt.hint, p.Errors[0].Error(), p.Errors[0].Hint)
+		}
+	}
+}
+
+func TestShadowingAndPredeclaredNames(t *testing.T) {
+	valid := []string{
+		"let x = 1; let f = fn(x) { x = 2; x };",
+		"const x = 1; let f = fn() { let x = 2; x = 3; };",
+		"let x = 1; x = 2; x += 3;",
+		"let x = 0; for (x in [1]) { }",
+		"if (true) { let x = 1; x } else { let x = 2; x }",
+		"while (false) { const y = 1; } for (i in [1]) { let y = 2; }",
+		"if (true) { let x = 1; } let x = 2;",
+	}
+	for _, input := range valid {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		checkParserErrors(t, p)
+	}
+
+	p := New(lexer.New("let a = 1; b = 2;"))
+	p.Declare("a", false)
+	p.Declare("b", true)
+	p.ParseProgram()
+	if len(p.Errors) != 2 || p.Errors[1].Hint != "declared earlier" {
+		t.Errorf("predeclared names are not checked. got=%v", p.Errors)
+	}
+}
+
+func TestDocComments(t *testing.T) {
+	input := `
+/// Adds two numbers.
+/// Returns their sum.
+let add = fn(a, b) { a + b };
+
+/// The answer.
+const answer = 42;
+
+// a plain comment is not documentation
+let plain = 1;
+
+let apply = fn(f) { f(1) };
+apply(/// inline doc
+fn(x) { x });
+`
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	add := program.Statements[0].(*ast.LetStatement)
+	if add.Doc != "Adds two numbers.\nReturns their sum." {
+		t.Errorf("wrong let doc. got=%q", add.Doc)
+	}
+	if add.Value.(*ast.FunctionLiteral).Doc != add.Doc {
+		t.Errorf("function literal did not get the let doc. got=%q", add.Value.(*ast.FunctionLiteral).Doc)
+	}
+	if doc := program.Statements[1].(*ast.LetStatement).Doc; doc != "The answer." {
+		t.Errorf("wrong const doc. got=%q", doc)
+	}
+	if doc := program.Statements[2].(*ast.LetStatement).Doc; doc != "" {
+		t.Errorf("plain comment became doc. got=%q", doc)
+	}
+	if doc := program.Statements[3].(*ast.LetStatement).Doc; doc != "" {
+		t.Errorf("unexpected doc. got=%q", doc)
+	}
+
+	call := program.Statements[4].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	if doc := call.Arguments[0].(*ast.FunctionLiteral).Doc; doc != "inline doc" {
+		t.Errorf("wrong function literal doc. got=%q", doc)
+	}
+}
+
+func TestUnterminatedBlockComment(t *testing.T) {
+	p := New(lexer.New("let x = 1;\n/* never closed"))
+	p.ParseProgram()
+
+	if len(p.Errors) != 1 {
+		t.Fatalf("expected 1 error, got %v", p.Errors)
+	}
+	if p.Errors[0].Span.Start.Line != 2 || p.Errors[0].Hint != "block comment is not closed with */" {
+		t.Errorf("wrong error. got=%s (%q)", p.Errors[0].Error(), p.Errors[0].Hint)
+	}
+}
+
+func TestReadError(t *testing.T) {
+	input := io.MultiReader(strings.NewReader("let x = 1;\nlet y"), iotest.ErrReader(errors.New("disk failure")))
+	p := New(lexer.NewReader(input))
+	p.ParseProgram()
+
+	if len(p.Errors) == 0 {
+		t.Fatalf("expected errors, got none")
+	}
+	if got := p.Errors[len(p.Errors)-1].Error(); got != "2:6: cannot read input: disk failure" {
+		t.Errorf("wrong error. got=%s", got)
+	}
+}
+
+func TestParserErrorPositions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x 5;", "1:7: expected next token to be: = but was: INT"},
+		{"let x = 1;\nadd(1, 2;", "2:9: expected next token to be: ) but was: ;"},
+		{"\n\n   )", "3:4: no prefix parse function found for token type ) found"},
+		{"1 = 2;", "1:3: invalid assignment target: 1"},
+		{"let ціна = \xe2\x82;", "1:12: invalid UTF-8 encoding: \"\\xe2\""},
+		{"f() += 1;", "1:5: invalid assignment target: f()"},
+		{"a ? 1;", "1:6: expected next token to be: : but was: ;"},
+		{"a?.b;", "1:4: expected [ or ( after ?., got IDENT"},
+		{"a?.[0] = 1;", "1:8: invalid assignment target: (a?.[0])"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		if len(p.Errors) == 0 {
+			t.Errorf("input %q: expected parser errors, got none", tt.input)
+			continue
+		}
+		if p.Errors[0].Error() != tt.expected {
+			t.Errorf("input %q: wrong error. expected=%q, got=%q", tt.input, tt.expected, p.Errors[0].Error())
+		}
+	}
+}
+
+func TestParserErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedErrors []string
+		expectedString string
+	}{
+		{
+			"let x 5; let y = 10; let = 3; y;",
+			[]string{
+				"1:7: expected next token to be: = but was: INT",
+				"1:26: expected next token to be: IDENT but was: =",
+			},
+			"<bad statement>let y = 10;<bad statement>y",
+		},
+		{
+			"let a = (1 + ; let b = 2;",
+			[]string{
+				"1:14: no prefix parse function found for token type ; found",
+			},
+			"<bad statement>let b = 2;",
+		},
+		{
+			"let f = fn(x) { let y = ; x }; f(1);",
+			[]string{
+				"1:25: no prefix parse function found for token type ; found",
+			},
+			"let f = fn(x) <bad statement>x;f(1)",
+		},
+		{
+			"if (x) { return }; 5",
+			[]string{
+				"1:17: no prefix parse function found for token type } found",
+			},
+			"if x<bad statement>5",
+		},
+		{
+			"fn(1, 2) { 1 }",
+			[]string{
+				"1:4: expected next token to be: IDENT but was: INT",
+			},
+			"<bad statement>",
+		},
+		{
+			"let x = fn() { 1",
+			[]string{
+				"1:17: expected next token to be: } but was: EOF",
+			},
+			"<bad statement>",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		if len(p.Errors) != len(tt.expectedErrors) {
+			for _, e := range p.Errors {
+				t.Logf("parser error: %s", e.Error())
+			}
+			t.Errorf("input %q: wrong number of errors. expected=%d, got=%d", tt.input, len(tt.expectedErrors), len(p.Errors))
+			continue
+		}
+		for i, expected := range tt.expectedErrors {
+			if p.Errors[i].Error() != expected {
+				t.Errorf("input %q: wrong error[%d]. expected=%q, got=%q", tt.input, i, expected, p.Errors[i].Error())
+			}
+		}
+		if program.String() != tt.expectedString {
+			t.Errorf("input %q: wrong partial program. expected=%q, got=%q", tt.input, tt.expectedString, program.String())
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"interpreter/ast"
+	"interpreter/token"
+)
+
+// declaration - ім'я, оголошене через let, const, параметр функції чи змінну for
+type declaration struct {
+	tok      token.Token
+	constant bool
+}
+
+// scope - імена програми, функції або одного блоку. блоки if і циклів не створюють
+// власного середовища під час виконання, тому ім'я з блоку не може перекривати ім'я
+// зовнішнього блоку тієї ж функції, але сусідні блоки (then і else) не конфліктують
+type scope struct {
+	names map[string]declaration
+	// function - скоуп програми чи функції: межа, за якою перекривати імена можна
+	function bool
+}
+
+// Declare додає ім'я, оголошене до початку розбору (наприклад попередніми рядками REPL),
+// щоб повторний let і присвоєння константі ловились так само, як у межах однієї програми
+func (p *Parser) Declare(name string, constant bool) {
+	p.scopes[0].names[name] = declaration{tok: token.Token{Type: token.IDENT, Literal: name}, constant: constant}
+}
+
+// enterScope відкриває скоуп функції, enterBlock - скоуп блоку всередині неї
+func (p *Parser) enterScope() {
+	p.scopes = append(p.scopes, scope{names: map[string]declaration{}, function: true})
+}
+
+func (p *Parser) enterBlock() {
+	p.scopes = append(p.scopes, scope{names: map[string]declaration{}})
+}
+
+func (p *Parser) leaveScope() {
+	p.scopes = p.scopes[:len(p.scopes)-1]
+}
+
+// lookupInFunction шукає ім'я в поточному блоці і зовнішніх блоках до скоупу функції включно
+func (p *Parser) lookupInFunction(name string) (declaration, bool) {
+	for i := len(p.scopes) - 1; i >= 0; i-- {
+		if decl, ok := p.scopes[i].names[name]; ok {
+			return decl, true
+		}
+		if p.scopes[i].function {
+			break
+		}
+	}
+	return declaration{}, false
+}
+
+// declare оголошує ім'я в поточному скоупі. повторне оголошення в тому ж блоці
+// або в зовнішньому блоці тієї ж функції - помилка
+func (p *Parser) declare(ident *ast.Identifier, constant bool) {
+	if previous, ok := p.lookupInFunction(ident.Value); ok {
+		hint := declaredAt(previous)
+		if !previous.constant {
+			hint += "; use assignment to change its value"
+		}
+		p.addError(ident.Token, hint, "identifier already declared: %s", ident.Value)
+		return
+	}
+	p.scopes[len(p.scopes)-1].names[ident.Value] = declaration{tok: ident.Token, constant: constant}
+}
+
+// declareLoopVariable - змінна for перезаписується на кожному проході, тому вона може
+// збігатися з let, але не з const
+func (p *Parser) declareLoopVariable(ident *ast.Identifier) {
+	if previous, ok := p.lookupInFunction(ident.Value); ok {
+		if previous.constant {
+			p.constantAssignmentError(ident, previous)
+		}
+		return
+	}
+	p.scopes[len(p.scopes)-1].names[ident.Value] = declaration{tok: ident.Token}
+}
+
+// checkAssignment перевіряє, що найближче оголошення імені - не const
+func (p *Parser) checkAssignment(ident *ast.Identifier) {
+	for i := len(p.scopes) - 1; i >= 0; i-- {
+		if decl, ok := p.scopes[i].names[ident.Value]; ok {
+			if decl.constant {
+				p.constantAssignmentError(ident, decl)
+			}
+			return
+		}
+	}
+}
+
+func (p *Parser) constantAssignmentError(ident *ast.Identifier, decl declaration) {
+	p.addError(ident.Token, declaredAt(decl), "cannot assign to constant: %s", ident.Value)
+}
+
+func declaredAt(decl declaration) string {
+	if !decl.tok.Pos.IsValid() {
+		return "declared earlier"
+	}
+	return "declared at " + decl.tok.Pos.String()
+}
@@ -3,42 +3,241 @@ package repl
 import (
 	"bufio"
 	"fmt"
+	"interpreter/ast"
+	"interpreter/diagnostic"
 	"interpreter/evaluator"
 	"interpreter/lexer"
 	"interpreter/object"
 	"interpreter/parser"
+	"interpreter/token"
 	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 const PROMPT = ">> "
 
+// CONTINUATION_PROMPT показується, поки введений код має незакриті дужки
+const CONTINUATION_PROMPT = ".. "
+
+// HISTORY_FILE - файл історії в домашній директорії користувача
+const HISTORY_FILE = ".monkey_history"
+
+const helpText = `:help             show this help
+:quit, :q         exit the REPL
+:env              list bindings of the global environment, marking constants
+:ast <code>       print the parsed tree of <code>
+:tokens <code>    print the tokens of <code>
+:load <file>      run a script file in the current environment
+:history          print the input history
+`
+
+// Start запускає REPL з історією у ~/.monkey_history
 func Start(in io.Reader, out io.Writer) {
-	env := object.NewEnvironment()
+	StartWithHistory(in, out, defaultHistoryPath())
+}
+
+// StartWithHistory запускає REPL, дописуючи кожен введений вираз у historyPath.
+// порожній historyPath вимикає збереження історії. повертається на EOF або :quit
+func StartWithHistory(in io.Reader, out io.Writer, historyPath string) {
+	r := &repl{
+		out:         out,
+		env:         object.NewEnvironment(),
+		historyPath: historyPath,
+		history:     loadHistory(historyPath),
+	}
 	scanner := bufio.NewScanner(in)
+
+	var input strings.Builder
 	for {
-		fmt.Fprintf(out, PROMPT)
-		scanner.Scan()
+		if input.Len() == 0 {
+			fmt.Fprint(out, PROMPT)
+		} else {
+			fmt.Fprint(out, CONTINUATION_PROMPT)
+		}
+
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return
+		}
 		line := scanner.Text()
 
-		l := lexer.New(line)
-		p := parser.New(l)
+		if input.Len() == 0 && strings.HasPrefix(strings.TrimSpace(line), ":") {
+			r.addHistory(strings.TrimSpace(line))
+			if !r.runCommand(strings.TrimSpace(line)) {
+				return
+			}
+			continue
+		}
+
+		input.WriteString(line)
+		input.WriteString("\n")
+		if !isComplete(input.String()) {
+			continue
+		}
+
+		source := input.String()
+		input.Reset()
+		if strings.TrimSpace(source) == "" {
+			continue
+		}
+		r.addHistory(strings.TrimRight(source, "\n"))
+		r.eval("", source)
+	}
+}
+
+type repl struct {
+	out         io.Writer
+	env         *object.Environment
+	historyPath string
+	history     []string
+}
+
+func (r *repl) eval(filename, source string) {
+	l := lexer.NewWithFilename(filename, source)
+	p := parser.New(l)
+	// змінну з попередніх рядків можна оголосити знову, а константу - ні
+	for _, name := range r.env.Names() {
+		if binding, _ := r.env.Lookup(name); binding.Constant {
+			p.Declare(name, true)
+		}
+	}
+	program := p.ParseProgram()
+	if len(p.Errors) > 0 {
+		printParserErrors(r.out, source, p.Errors)
+		return
+	}
+	obj := evaluator.Eval(program, r.env)
+	if err, ok := obj.(*object.Error); ok {
+		printRuntimeError(r.out, source, err)
+		return
+	}
+	if obj != nil {
+		io.WriteString(r.out, obj.Inspect())
+		io.WriteString(r.out, "\n")
+	}
+}
+
+// runCommand виконує мета-команду. повертає false, якщо треба вийти з REPL
+func (r *repl) runCommand(line string) bool {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case ":quit", ":q":
+		return false
+	case ":help":
+		io.WriteString(r.out, helpText)
+	case ":env":
+		for _, name := range r.env.Names() {
+			binding, _ := r.env.Lookup(name)
+			if binding.Constant {
+				io.WriteString(r.out, "const ")
+			}
+			fmt.Fprintf(r.out, "%s = %s\n", name, binding.Value.Inspect())
+		}
+	case ":ast":
+		p := parser.New(lexer.New(arg))
 		program := p.ParseProgram()
 		if len(p.Errors) > 0 {
-			printParserErrors(out, p.Errors)
-			continue
+			printParserErrors(r.out, arg, p.Errors)
+		}
+		io.WriteString(r.out, ast.Dump(program))
+	case ":tokens":
+		l := lexer.New(arg)
+		for tok := l.NextToken(); ; tok = l.NextToken() {
+			fmt.Fprintf(r.out, "%s %s %q\n", tok.Pos, tok.Type, tok.Literal)
+			if tok.Type == token.EOF {
+				break
+			}
 		}
-		obj := evaluator.Eval(program, env)
-		if obj != nil {
-			io.WriteString(out, obj.Inspect())
-			io.WriteString(out, "\n")
+	case ":load":
+		if arg == "" {
+			fmt.Fprintln(r.out, "usage: :load <file>")
+			break
+		}
+		source, err := os.ReadFile(arg)
+		if err != nil {
+			fmt.Fprintf(r.out, "error: %s\n", err)
+			break
+		}
+		r.eval(arg, string(source))
+	case ":history":
+		for i, entry := range r.history {
+			fmt.Fprintf(r.out, "%4d  %s\n", i+1, entry)
+		}
+	default:
+		fmt.Fprintf(r.out, "unknown command %s, try :help\n", name)
+	}
+	return true
+}
+
+// isComplete повертає false, поки в коді є незакриті (, [, { або /*.
+// дужки рахуються по токенах, тому дужки всередині рядків не враховуються
+func isComplete(source string) bool {
+	depth := 0
+	l := lexer.New(source)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case token.LPAREN, token.LBRACKET, token.LBRACE:
+			depth++
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			depth--
+		case token.ILLEGAL:
+			// незакритий /* - коментар продовжиться в наступному рядку
+			if strings.HasPrefix(tok.Literal, "/*") {
+				return false
+			}
 		}
 	}
+	return depth <= 0
+}
 
+func (r *repl) addHistory(entry string) {
+	r.history = append(r.history, entry)
+	if r.historyPath == "" {
+		return
+	}
+	f, err := os.OpenFile(r.historyPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	// запис у лапках, щоб багаторядковий ввід займав один рядок файлу
+	fmt.Fprintln(f, strconv.Quote(entry))
 }
 
-func printParserErrors(out io.Writer, s []string) {
-	for _, msg := range s {
-		io.WriteString(out, "\t"+msg+"\n")
-		// fmt.Fprintf(out, "\t"+msg+"\n")
+func loadHistory(path string) []string {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
 	}
+	var history []string
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		if entry, err := strconv.Unquote(line); err == nil {
+			history = append(history, entry)
+		}
+	}
+	return history
+}
+
+func defaultHistoryPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, HISTORY_FILE)
+}
+
+func printParserErrors(out io.Writer, source string, errors []diagnostic.Diagnostic) {
+	diagnostic.Render(out, source, errors)
+}
+
+func printRuntimeError(out io.Writer, source string, err *object.Error) {
+	diagnostic.Render(out, source, []diagnostic.Diagnostic{diagnostic.FromRuntimeError(err)})
 }
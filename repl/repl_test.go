@@ -0,0 +1,106 @@
+package repl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMultiLineInput(t *testing.T) {
+	input := "let add = fn(a, b) {\n  a + b\n};\nadd(1,\n 2)\n"
+
+	var out bytes.Buffer
+	StartWithHistory(strings.NewReader(input), &out, "")
+
+	expected := ">> .. .. >> .. 3\n>> \n"
+	if out.String() != expected {
+		t.Errorf("wrong output. expected=%q, got=%q", expected, out.String())
+	}
+}
+
+func TestIsComplete(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"let x = 1;", true},
+		{"fn(x) {", false},
+		{"fn(x) {\n x }", true},
+		{"[1, 2,", false},
+		{`"{"`, true},
+		{"}", true},
+		{"1 /* comment", false},
+		{"1 /* comment */", true},
+	}
+
+	for _, tt := range tests {
+		if got := isComplete(tt.input); got != tt.expected {
+			t.Errorf("isComplete(%q) wrong. expected=%t, got=%t", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestMetaCommands(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "lib.monkey")
+	if err := os.WriteFile(script, []byte("let double = fn(x) { x * 2 };"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let a = 1;\nlet b = \"s\";\n:env\n", []string{"a = 1\n", "b = s\n"}},
+		{"const c = 1;\nlet d = 2;\n:env\n", []string{"const c = 1\nd = 2\n"}},
+		{"let a = 1;\nlet a = 2;\na\n", []string{"2\n"}},
+		{"let a = 1; let a = 2;\n", []string{"identifier already declared: a"}},
+		{"const a = 1;\nlet a = 2;\n", []string{"identifier already declared: a"}},
+		{"const a = 1;\na = 2;\n", []string{"cannot assign to constant: a"}},
+		{":ast 1 + x\n", []string{"Program \"1\" 1:1\n", "Expression: InfixExpression \"+\" 1:3\n"}},
+		{":tokens let x\n", []string{"1:1 LET \"let\"\n", "1:5 IDENT \"x\"\n", "1:6 EOF \"\"\n"}},
+		{":load " + script + "\ndouble(4)\n", []string{"8\n"}},
+		{":load\n", []string{"usage: :load <file>\n"}},
+		{":nope\n", []string{"unknown command :nope"}},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		StartWithHistory(strings.NewReader(tt.input), &out, "")
+		for _, exp := range tt.expected {
+			if !strings.Contains(out.String(), exp) {
+				t.Errorf("input %q: output %q does not contain %q", tt.input, out.String(), exp)
+			}
+		}
+	}
+
+	// порожнє середовище - :env нічого не друкує
+	var out bytes.Buffer
+	StartWithHistory(strings.NewReader(":env\n"), &out, "")
+	if out.String() != ">> >> \n" {
+		t.Errorf(":env printed bindings for an empty environment. got=%q", out.String())
+	}
+
+	out.Reset()
+	StartWithHistory(strings.NewReader(":quit\n1 + 1\n"), &out, "")
+	if strings.Contains(out.String(), "2") {
+		t.Errorf(":quit did not stop the REPL. got=%q", out.String())
+	}
+}
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), HISTORY_FILE)
+
+	var out bytes.Buffer
+	StartWithHistory(strings.NewReader("let s = \"a\\n\";\nfn(x) {\n x }\n"), &out, path)
+
+	out.Reset()
+	StartWithHistory(strings.NewReader(":history\n"), &out, path)
+
+	expected := ">>    1  let s = \"a\\n\";\n   2  fn(x) {\n x }\n   3  :history\n>> \n"
+	if out.String() != expected {
+		t.Errorf("wrong history. expected=%q, got=%q", expected, out.String())
+	}
+}
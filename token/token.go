@@ -1,5 +1,7 @@
 package token
 
+import "fmt"
+
 type TokenType string
 
 const (
@@ -7,8 +9,10 @@ const (
 	EOF     = "EOF"
 
 	//Identifiers + literals
-	IDENT = "IDENT"
-	INT   = "INT"
+	IDENT  = "IDENT"
+	INT    = "INT"
+	FLOAT  = "FLOAT"
+	STRING = "STRING"
 
 	//operators
 	ASSIGN   = "="
@@ -17,46 +21,117 @@ const (
 	BANG     = "!"
 	ASTERISK = "*"
 	SLASH    = "/"
+	PERCENT  = "%"
+
+	AMPERSAND   = "&"
+	PIPE        = "|"
+	CARET       = "^"
+	TILDE       = "~"
+	SHIFT_LEFT  = "<<"
+	SHIFT_RIGHT = ">>"
+
+	QUESTION     = "?"
+	COALESCE     = "??"
+	QUESTION_DOT = "?."
+
+	PLUS_ASSIGN     = "+="
+	MINUS_ASSIGN    = "-="
+	ASTERISK_ASSIGN = "*="
+	SLASH_ASSIGN    = "/="
 
-	LT = "<"
-	GT = ">"
+	LT    = "<"
+	GT    = ">"
+	LT_EQ = "<="
+	GT_EQ = ">="
 
 	//bools
 	EQ     = "=="
 	NOT_EQ = "!="
+	AND    = "&&"
+	OR     = "||"
 
 	//delimiters
 	COMMA     = ","
 	SEMICOLON = ";"
+	COLON     = ":"
 
 	LPAREN = "("
 	RPAREN = ")"
 	LBRACE = "{"
 	RBRACE = "}"
 
+	LBRACKET = "["
+	RBRACKET = "]"
+
 	//keywords
 	FUNCTION = "FUNCTION"
 	LET      = "LET"
+	CONST    = "const"
 	FALSE    = "false"
 	TRUE     = "true"
 	RETURN   = "return"
 	IF       = "if"
 	ELSE     = "else"
+	WHILE    = "while"
+	FOR      = "for"
+	IN       = "in"
+	BREAK    = "break"
+	CONTINUE = "continue"
 )
 
 type Token struct {
 	Type    TokenType
 	Literal string
+	Pos     Position
+	// End - позиція одразу після останнього символу токена
+	End Position
+	// Doc - текст /// коментарів, що стоять безпосередньо перед токеном, без "///"
+	Doc string
+}
+
+// Position - місце в коді, де починається токен.
+// Offset рахується в байтах від 0, Line та Column - від 1
+type Position struct {
+	Filename string
+	Offset   int
+	Line     int
+	Column   int
+}
+
+// IsValid повертає false для нульової позиції (вузли, створені не парсером)
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String форматує позицію як file:line:column або line:column якщо файлу немає
+func (p Position) String() string {
+	if !p.IsValid() {
+		if p.Filename != "" {
+			return p.Filename
+		}
+		return "-"
+	}
+	s := fmt.Sprintf("%d:%d", p.Line, p.Column)
+	if p.Filename != "" {
+		s = p.Filename + ":" + s
+	}
+	return s
 }
 
 var keywords = map[string]TokenType{
-	"let":    LET,
-	"fn":     FUNCTION,
-	"false":  FALSE,
-	"true":   TRUE,
-	"return": RETURN,
-	"if":     IF,
-	"else":   ELSE,
+	"let":      LET,
+	"const":    CONST,
+	"fn":       FUNCTION,
+	"false":    FALSE,
+	"true":     TRUE,
+	"return":   RETURN,
+	"if":       IF,
+	"else":     ELSE,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
 }
 
 func LookupIdent(input string) TokenType {
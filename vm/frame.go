@@ -0,0 +1,23 @@
+package vm
+
+import (
+	"interpreter/code"
+	"interpreter/object"
+)
+
+// Frame - виклик функції: замикання, адреса поточної інструкції і початок локальних змінних на стеку
+type Frame struct {
+	cl          *object.Closure
+	ip          int
+	basePointer int
+	// loops - висота стеку на вході в кожен цикл, що зараз виконується, найближчий останній
+	loops []int
+}
+
+func NewFrame(cl *object.Closure, basePointer int) *Frame {
+	return &Frame{cl: cl, ip: -1, basePointer: basePointer}
+}
+
+func (f *Frame) Instructions() code.Instructions {
+	return f.cl.Fn.Instructions
+}
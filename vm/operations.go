@@ -0,0 +1,277 @@
+package vm
+
+import (
+	"interpreter/object"
+	"math"
+	"math/big"
+)
+
+// операції повторюють evaluator, включно з порядком перевірок і текстами помилок
+
+func executeInfixOperation(operator string, left, right object.Object) object.Object {
+	l, lok := left.(*object.Integer)
+	r, rok := right.(*object.Integer)
+	switch {
+	case lok && rok:
+		return executeIntegerOperation(operator, l, r)
+	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
+		return executeBigIntegerOperation(operator, bigValue(left), bigValue(right))
+	case object.IsNumber(left) && object.IsNumber(right):
+		l, _ := object.FloatValue(left)
+		r, _ := object.FloatValue(right)
+		return executeFloatOperation(operator, l, r)
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return executeStringOperation(operator, left.(*object.String), right.(*object.String))
+	case operator == "==":
+		return nativeBoolToBooleanObject(left == right)
+	case operator == "!=":
+		return nativeBoolToBooleanObject(left != right)
+	case left.Type() != right.Type():
+		return object.NewError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	default:
+		return object.NewError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// vm завжди рахує в режимі evaluator.ArithmeticChecked
+func executeIntegerOperation(operator string, left, right *object.Integer) object.Object {
+	switch operator {
+	case "+", "-", "*", "/", "%", "&", "|", "^", "<<", ">>":
+		if err := checkDivisor(operator, right.Value == 0); err != nil {
+			return err
+		}
+		if (operator == "<<" || operator == ">>") && right.Value < 0 {
+			return object.NewError("negative shift count: %d", right.Value)
+		}
+		result, ok := object.IntegerOperation(operator, left.Value, right.Value)
+		if !ok {
+			return object.NewError("integer overflow: %d %s %d", left.Value, operator, right.Value)
+		}
+		return &object.Integer{Value: result}
+	case ">":
+		return nativeBoolToBooleanObject(left.Value > right.Value)
+	case "<":
+		return nativeBoolToBooleanObject(left.Value < right.Value)
+	case ">=":
+		return nativeBoolToBooleanObject(left.Value >= right.Value)
+	case "<=":
+		return nativeBoolToBooleanObject(left.Value <= right.Value)
+	case "==":
+		return nativeBoolToBooleanObject(left.Value == right.Value)
+	case "!=":
+		return nativeBoolToBooleanObject(left.Value != right.Value)
+	default:
+		return object.NewError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// *object.BigInteger приходить у vm лише від вбудованих функцій хоста;
+// порівняння працюють, а арифметика з ним завжди переповнює int64
+func executeBigIntegerOperation(operator string, left, right *big.Int) object.Object {
+	switch operator {
+	case "+", "-", "*", "/", "%", "&", "|", "^", "<<", ">>":
+		return object.NewError("integer overflow: %s %s %s", left, operator, right)
+	case ">":
+		return nativeBoolToBooleanObject(left.Cmp(right) > 0)
+	case "<":
+		return nativeBoolToBooleanObject(left.Cmp(right) < 0)
+	case ">=":
+		return nativeBoolToBooleanObject(left.Cmp(right) >= 0)
+	case "<=":
+		return nativeBoolToBooleanObject(left.Cmp(right) <= 0)
+	case "==":
+		return nativeBoolToBooleanObject(left.Cmp(right) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(left.Cmp(right) != 0)
+	default:
+		return object.NewError("unknown operator: %s %s %s", object.INTEGER_OBJ, operator, object.INTEGER_OBJ)
+	}
+}
+
+func bigValue(obj object.Object) *big.Int {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return big.NewInt(obj.Value)
+	case *object.BigInteger:
+		return obj.Value
+	default:
+		return nil
+	}
+}
+
+func executeFloatOperation(operator string, left, right float64) object.Object {
+	switch operator {
+	case "+":
+		return &object.Float{Value: left + right}
+	case "-":
+		return &object.Float{Value: left - right}
+	case "*":
+		return &object.Float{Value: left * right}
+	case "/", "%":
+		if err := checkDivisor(operator, right == 0); err != nil {
+			return err
+		}
+		if operator == "%" {
+			return &object.Float{Value: math.Mod(left, right)}
+		}
+		return &object.Float{Value: left / right}
+	case ">":
+		return nativeBoolToBooleanObject(left > right)
+	case "<":
+		return nativeBoolToBooleanObject(left < right)
+	case ">=":
+		return nativeBoolToBooleanObject(left >= right)
+	case "<=":
+		return nativeBoolToBooleanObject(left <= right)
+	case "==":
+		return nativeBoolToBooleanObject(left == right)
+	case "!=":
+		return nativeBoolToBooleanObject(left != right)
+	default:
+		return object.NewError("unknown operator: %s %s %s", object.FLOAT_OBJ, operator, object.FLOAT_OBJ)
+	}
+}
+
+func executeStringOperation(operator string, left, right *object.String) object.Object {
+	switch operator {
+	case "+":
+		return &object.String{Value: left.Value + right.Value}
+	case "==":
+		return nativeBoolToBooleanObject(left.Value == right.Value)
+	case "!=":
+		return nativeBoolToBooleanObject(left.Value != right.Value)
+	case "<":
+		return nativeBoolToBooleanObject(left.Value < right.Value)
+	case ">":
+		return nativeBoolToBooleanObject(left.Value > right.Value)
+	case "<=":
+		return nativeBoolToBooleanObject(left.Value <= right.Value)
+	case ">=":
+		return nativeBoolToBooleanObject(left.Value >= right.Value)
+	default:
+		return object.NewError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func checkDivisor(operator string, zero bool) *object.Error {
+	if !zero {
+		return nil
+	}
+	if operator == "%" {
+		return object.NewError("modulo by zero")
+	}
+	if operator == "/" {
+		return object.NewError("division by zero")
+	}
+	return nil
+}
+
+func executeBangOperator(operand object.Object) object.Object {
+	switch operand {
+	case True:
+		return False
+	case False:
+		return True
+	case Null:
+		return True
+	default:
+		return False
+	}
+}
+
+func executeMinusOperator(operand object.Object) object.Object {
+	if float, ok := operand.(*object.Float); ok {
+		return &object.Float{Value: -float.Value}
+	}
+	if integer, ok := operand.(*object.BigInteger); ok {
+		return object.NewInteger(new(big.Int).Neg(integer.Value))
+	}
+	integer, ok := operand.(*object.Integer)
+	if !ok {
+		return object.NewError("unknown operator: -%s", operand.Type())
+	}
+	if integer.Value == math.MinInt64 {
+		return object.NewError("integer overflow: -(%d)", integer.Value)
+	}
+	return &object.Integer{Value: -integer.Value}
+}
+
+func executeBitwiseNotOperator(operand object.Object) object.Object {
+	if integer, ok := operand.(*object.BigInteger); ok {
+		return object.NewInteger(new(big.Int).Not(integer.Value))
+	}
+	integer, ok := operand.(*object.Integer)
+	if !ok {
+		return object.NewError("unknown operator: ~%s", operand.Type())
+	}
+	return &object.Integer{Value: ^integer.Value}
+}
+
+func executeIndexExpression(left, index object.Object) object.Object {
+	array, isArray := left.(*object.Array)
+	switch {
+	case isArray && index.Type() == object.INTEGER_OBJ:
+		integer, ok := index.(*object.Integer)
+		if !ok {
+			// *object.BigInteger завжди за межами масиву
+			return Null
+		}
+		return executeArrayIndex(array, integer)
+	case left.Type() == object.HASH_OBJ:
+		return executeHashIndex(left.(*object.Hash), index)
+	default:
+		return object.NewError("index operator not supported: %s[%s]", left.Type(), index.Type())
+	}
+}
+
+// executeSetIndex виконує left[index] = value, а для непорожнього operator -
+// left[index] = left[index] operator value
+func executeSetIndex(left, index, value object.Object, operator string) object.Object {
+	if operator != "" {
+		current := executeIndexExpression(left, index)
+		if current.Type() == object.ERROR_OBJ {
+			return current
+		}
+		value = executeInfixOperation(operator, current, value)
+		if value.Type() == object.ERROR_OBJ {
+			return value
+		}
+	}
+	return object.SetIndex(left, index, value)
+}
+
+func executeArrayIndex(array *object.Array, index *object.Integer) object.Object {
+	idx := index.Value
+	length := int64(len(array.Elements))
+
+	if idx < 0 {
+		idx += length
+	}
+
+	if idx < 0 || idx >= length {
+		return Null
+	}
+
+	return array.Elements[idx]
+}
+
+func executeHashIndex(hash *object.Hash, index object.Object) object.Object {
+	key, ok := index.(object.Hashable)
+	if !ok {
+		return object.NewError("unusable as hash key: %s", index.Type())
+	}
+
+	value, ok := hash.Get(key)
+	if !ok {
+		return Null
+	}
+
+	return value
+}
+
+func nativeBoolToBooleanObject(input bool) *object.Boolean {
+	if input {
+		return True
+	}
+	return False
+}
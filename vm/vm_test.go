@@ -0,0 +1,345 @@
+package vm
+
+import (
+	"interpreter/compiler"
+	"interpreter/lexer"
+	"interpreter/object"
+	"interpreter/parser"
+	"math/big"
+	"testing"
+)
+
+type vmTestCase struct {
+	input    string
+	expected any
+}
+
+func TestIntegerArithmetic(t *testing.T) {
+	tests := []vmTestCase{
+		{"1 + 2", 3},
+		{"50 / 2 * 2 + 10 - 5", 55},
+		{"-50 + 100 + -50", 0},
+		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestFloatArithmetic(t *testing.T) {
+	tests := []vmTestCase{
+		{"1.5 + 1.5", "3.0"},
+		{"-2.5 * 2", "-5.0"},
+		{"7 / 2.0", "3.5"},
+		{"1 < 1.5", true},
+		{"2.0 == 2", true},
+		{"int(2.9) + float(1)", "3.0"},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestNewOperators(t *testing.T) {
+	tests := []vmTestCase{
+		{"7 % 3", 1},
+		{"2 <= 2", true},
+		{"1 >= 2", false},
+		{"true && 1", true},
+		{"false && missing", false},
+		{"true || missing", true},
+		{"[][0] || false", false},
+		{"0b1100 & 0b1010 | 1", 9},
+		{"0b1100 ^ 0b1010", 6},
+		{"~5 >> 1 << 2", -12},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestBooleanExpressions(t *testing.T) {
+	tests := []vmTestCase{
+		{"1 < 2", true},
+		{"1 > 2", false},
+		{"(1 < 2) == true", true},
+		{"!5", false},
+		{"!!5", true},
+		{`"a" < "b"`, true},
+		{"!(if (false) { 5; })", true},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestConditionals(t *testing.T) {
+	tests := []vmTestCase{
+		{"if (true) { 10 }", 10},
+		{"if (1 > 2) { 10 }", Null},
+		{"if (1 > 2) { 10 } else { 20 }", 20},
+		{"if ((if (false) { 10 })) { 10 } else { 20 }", 20},
+		{"if (true) { let a = 1; }", Null},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestConditionalAndCoalesce(t *testing.T) {
+	tests := []vmTestCase{
+		{"1 > 2 ? 10 : 20", 20},
+		{"let f = fn(x) { x ? x : -1 }; f(5) + f([][0])", 4},
+		{"[][0] ?? 3", 3},
+		{"false ?? 3", false},
+		{`let h = {"a": [1, 2]}; h["a"]?.[0] + (h["b"]?.[0] ?? 10)`, 11},
+		{"let f = [][0]; f?.(1)", Null},
+		{"let f = fn() { 7 }; f?.()", 7},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestGlobalsAndReturn(t *testing.T) {
+	tests := []vmTestCase{
+		{"let one = 1; let two = one + one; one + two", 3},
+		{"let a = 1; a = a + 1; a", 2},
+		{"let a = 1;", Null},
+		{"return 10; 9;", 10},
+		{"if (10 > 1) { if (10 > 1) { return 10; } return 1; }", 10},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestCollections(t *testing.T) {
+	tests := []vmTestCase{
+		{"[1, 2 * 2, 3 + 3]", "[1, 4, 6]"},
+		{"[1, 2, 3][-1]", 3},
+		{"[1, 2, 3][3]", Null},
+		{`{"a": 1, "b": 2}["b"]`, 2},
+		{`{"a": 1}["c"]`, Null},
+		{`{1: 2, true: 3}`, "{1: 2, true: 3}"},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestFunctionsAndClosures(t *testing.T) {
+	tests := []vmTestCase{
+		{"let f = fn(a, b) { a + b }; f(1, 2)", 3},
+		{"let f = fn() { }; f()", Null},
+		{"let f = fn() { return 1; 2 }; f()", 1},
+		{"let newAdder = fn(a) { fn(b) { a + b } }; newAdder(2)(3)", 5},
+		{`
+		let fib = fn(n) { if (n < 2) { return n; } fib(n - 1) + fib(n - 2) };
+		fib(15)`, 610},
+		{`
+		let wrapper = fn() {
+			let countDown = fn(x) { if (x == 0) { return 0; } countDown(x - 1) };
+			countDown(5)
+		};
+		wrapper()`, 0},
+		// глобальна функція може посилатись на ім'я, оголошене пізніше
+		{"let f = fn() { g() }; let g = fn() { 7 }; f()", 7},
+		{"let make = fn() { let c = 0; fn() { c = c + 1; c } }; let f = make(); f(); f()", 2},
+		{`len([1, 2]) + len("abc")`, 5},
+		{`first(rest(push([1, 2], 3)))`, 2},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestLoops(t *testing.T) {
+	tests := []vmTestCase{
+		{"let i = 0; while (i < 3) { i = i + 1; } i", 3},
+		{"let i = 0; while (true) { i = i + 1; if (i == 5) { break; } } i", 5},
+		{"let s = 0; for (x in [1, 2, 3]) { if (x == 2) { continue; } s = s + x; } s", 4},
+		{"let s = 0; for (i in range(4)) { for (j in range(i)) { s = s + j; } } s", 4},
+		{`let s = ""; for (k in {"a": 1, "b": 2}) { s = s + k; } s`, "ab"},
+		{"let f = fn(n) { let s = 0; for (i in range(n)) { if (i > 2) { return s; } s = s + i; } -1 }; [f(2), f(10)]", "[-1, 3]"},
+		{"while (false) { }", Null},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestAssignments(t *testing.T) {
+	tests := []vmTestCase{
+		{"let x = 1; x = 5; x", 5},
+		{"let x = 10; x += 5; x -= 3; x *= 2; x /= 4; x", 6},
+		{"let f = fn() { let n = 1; n += 2; n }; f()", 3},
+		{"let f = fn() { g = 3; }; let g = 0; f(); g", 3},
+		{"let arr = [1, 2]; arr[-1] += 5; arr", "[1, 7]"},
+		{`let h = {}; h["k"] = 1; h`, "{k: 1}"},
+	}
+
+	runVmTests(t, tests)
+}
+
+func TestRuntimeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"5 + true", "type mismatch: INTEGER + BOOLEAN"},
+		{"-true", "unknown operator: -BOOLEAN"},
+		{"true + false", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"foobar", "identifier not found: foobar"},
+		{"let f = fn() { g() }; f(); let g = 1;", "identifier not found: g"},
+		{"fn(a) { a }()", "wrong number of arguments: want=1, got=0"},
+		{"1()", "not a function: INTEGER"},
+		{"{fn(x) { x }: 1}", "unusable as hash key: FUNCTION"},
+		{"1[0]", "index operator not supported: INTEGER[INTEGER]"},
+		{`len(1)`, "argument to `len` not supported, got INTEGER"},
+		{"let f = fn(x) { f(x) }; f(1)", "stack overflow"},
+		{"1 / 0", "division by zero"},
+		{"1 / 0.0", "division by zero"},
+		{"1 << -1", "negative shift count: -1"},
+		{"1 << 63", "integer overflow: 1 << 63"},
+		{"~1.5", "unknown operator: ~FLOAT"},
+		{"2.5 + false", "type mismatch: FLOAT + BOOLEAN"},
+		{"9223372036854775807 + 1", "integer overflow: 9223372036854775807 + 1"},
+		{"-9223372036854775807 - 2", "integer overflow: -9223372036854775807 - 2"},
+		{"let min = -9223372036854775807 - 1; -min", "integer overflow: -(-9223372036854775808)"},
+		{`for (x in true) { }`, "cannot iterate over BOOLEAN"},
+		{"x = 1", "assignment to undeclared identifier: x"},
+		{"let inc = fn() { n += 1; }; const n = 0; inc()", "cannot assign to constant: n"},
+		{"let a = []; a[0] = 1", "index out of range: 0 (length 0)"},
+	}
+
+	for _, tt := range tests {
+		program := parse(tt.input).ParseProgram()
+
+		comp := compiler.New()
+		if err := comp.Compile(program); err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+
+		vm := New(comp.Bytecode())
+		err := vm.Run()
+		if err == nil {
+			t.Errorf("input %q: expected VM error but resulted in none", tt.input)
+			continue
+		}
+
+		rtErr, ok := err.(*object.Error)
+		if !ok {
+			t.Errorf("input %q: error is not *object.Error. got=%T (%s)", tt.input, err, err)
+			continue
+		}
+		if rtErr.Message != tt.expected {
+			t.Errorf("input %q: wrong VM error. want=%q, got=%q", tt.input, tt.expected, rtErr.Message)
+		}
+	}
+}
+
+func TestBigIntegerOperands(t *testing.T) {
+	// big = 2^64 приходить від хоста, vm сама таких чисел не створює
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"big > 1", true},
+		{"big == big", true},
+		{"-big", "-18446744073709551616"},
+		{"~big", "-18446744073709551617"},
+		{"-big - 1", "integer overflow: -18446744073709551616 - 1"},
+		{"[1, 2][big]", Null},
+		{"big + 1", "integer overflow: 18446744073709551616 + 1"},
+		{"1 * big", "integer overflow: 1 * 18446744073709551616"},
+		{"big + 1.5", "18446744073709552000.0"},
+	}
+
+	for _, tt := range tests {
+		symbols := compiler.NewSymbolTable()
+		symbols.Define("big")
+		comp := compiler.NewWithState(symbols, nil)
+		if err := comp.Compile(parse(tt.input).ParseProgram()); err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+
+		globals := make([]object.Object, GlobalsSize)
+		globals[0] = object.NewInteger(new(big.Int).Lsh(big.NewInt(1), 64))
+		vm := NewWithGlobalsStore(comp.Bytecode(), globals)
+		if err := vm.Run(); err != nil {
+			if rtErr, ok := err.(*object.Error); !ok || rtErr.Message != tt.expected {
+				t.Errorf("input %q: want %v, got error %s", tt.input, tt.expected, err)
+			}
+			continue
+		}
+		testExpectedObject(t, tt.input, tt.expected, vm.LastPoppedStackElem())
+	}
+}
+
+func TestRuntimeErrorPositions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = [1];\na[9223372036854775807 + 1]", "2:23: integer overflow: 9223372036854775807 + 1"},
+		{"let f = fn(x) {\n  x + true\n};\nf(1)", "2:5: type mismatch: INTEGER + BOOLEAN"},
+		{"for (x in 5) { }", "1:1: cannot iterate over INTEGER"},
+		{"len(1)", "1:4: argument to `len` not supported, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		comp := compiler.New()
+		if err := comp.Compile(parse(tt.input).ParseProgram()); err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+
+		err := New(comp.Bytecode()).Run()
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("input %q: wrong VM error. want=%q, got=%v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func parse(input string) *parser.Parser {
+	return parser.New(lexer.New(input))
+}
+
+func runVmTests(t *testing.T, tests []vmTestCase) {
+	t.Helper()
+
+	for _, tt := range tests {
+		p := parse(tt.input)
+		program := p.ParseProgram()
+		if len(p.Errors) > 0 {
+			t.Fatalf("input %q: parser errors: %v", tt.input, p.Errors)
+		}
+
+		comp := compiler.New()
+		if err := comp.Compile(program); err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+
+		vm := New(comp.Bytecode())
+		if err := vm.Run(); err != nil {
+			t.Fatalf("input %q: vm error: %s", tt.input, err)
+		}
+
+		testExpectedObject(t, tt.input, tt.expected, vm.LastPoppedStackElem())
+	}
+}
+
+func testExpectedObject(t *testing.T, input string, expected any, actual object.Object) {
+	t.Helper()
+
+	switch expected := expected.(type) {
+	case int:
+		integer, ok := actual.(*object.Integer)
+		if !ok || integer.Value != int64(expected) {
+			t.Errorf("input %q: want integer %d, got=%T (%+v)", input, expected, actual, actual)
+		}
+	case bool:
+		boolean, ok := actual.(*object.Boolean)
+		if !ok || boolean.Value != expected {
+			t.Errorf("input %q: want boolean %t, got=%T (%+v)", input, expected, actual, actual)
+		}
+	case string:
+		if actual == nil || actual.Inspect() != expected {
+			t.Errorf("input %q: want %s, got=%T (%+v)", input, expected, actual, actual)
+		}
+	case *object.Null:
+		if actual != Null {
+			t.Errorf("input %q: want Null, got=%T (%+v)", input, actual, actual)
+		}
+	}
+}